@@ -0,0 +1,100 @@
+// dashgen генерирует дашборды Grafana и файлы provisioning из определений
+// метрик. Каждый запрос панели проверяется по зарегистрированным метрикам.
+//
+//	go run ./cmd/dashgen                записать docker/grafana
+//	go run ./cmd/dashgen -check         проверить, что файлы актуальны
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/dashboards"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/metrics"
+	"gopkg.in/yaml.v3"
+)
+
+const header = "# Code generated by cmd/dashgen; DO NOT EDIT.\n"
+
+func main() {
+	dir := flag.String("dir", "docker/grafana", "directory for dashboards and provisioning files")
+	check := flag.Bool("check", false, "fail if the generated files are out of date instead of writing them")
+	flag.Parse()
+
+	if err := run(*dir, *check); err != nil {
+		fmt.Fprintln(os.Stderr, "dashgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(dir string, check bool) error {
+	service := dashboards.Service()
+	if err := dashboards.Validate(service, metrics.Names()); err != nil {
+		return err
+	}
+
+	files := make(map[string][]byte)
+	for _, d := range service {
+		data, err := json.MarshalIndent(d, "", "  ")
+		if err != nil {
+			return err
+		}
+		files[filepath.Join("dashboards", d.UID+".json")] = append(data, '\n')
+	}
+
+	datasources, err := marshalYAML(dashboards.Datasources())
+	if err != nil {
+		return err
+	}
+	files[filepath.Join("provisioning", "datasources", "prometheus.yml")] = datasources
+
+	providers, err := marshalYAML(dashboards.Providers())
+	if err != nil {
+		return err
+	}
+	files[filepath.Join("provisioning", "dashboards", "service.yml")] = providers
+
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+
+		if check {
+			current, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(current, data) {
+				return fmt.Errorf("%s is out of date, run go run ./cmd/dashgen", path)
+			}
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func marshalYAML(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(header)
+
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
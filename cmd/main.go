@@ -2,66 +2,268 @@ package main
 
 import (
 	"context"
+	stdlog "log"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
 	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/config"
+	adminHandler "github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/http-server/handlers/admin"
+	auditHandler "github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/http-server/handlers/audit"
 	userHandler "github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/http-server/handlers/user"
+	webhookHandler "github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/http-server/handlers/webhook"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/http-server/middleware/auth"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/http-server/middleware/httpmetrics"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/http-server/middleware/idempotency"
 	mvLogger "github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/http-server/middleware/logger"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/http-server/middleware/ratelimit"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/http-server/middleware/recoverer"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/http-server/middleware/reqlog"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/http-server/middleware/requestid"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/http-server/middleware/tracing"
 	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/infrastructure/database"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/infrastructure/telemetry"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/api/response"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/httpclient"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/lifecycle"
 	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/logger"
 	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/logger/sl"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/metrics"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/redact"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/repository/cache"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/repository/memory"
 	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/repository/postgres"
+	auditCase "github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/usecase/audit"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/usecase/authz"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/usecase/instrument"
 	userCase "github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/usecase/user"
+	webhookCase "github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/usecase/webhook"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 )
 
 func main() {
 	cfg := config.MustLoad()
-	log := logger.New(cfg.Env)
+
+	statsd, err := metrics.Setup(cfg.Metrics)
+	if err != nil {
+		stdlog.Fatalf("failed to init metrics: %s", err)
+	}
+
+	redactor := redact.New(cfg.Redact.Keys)
+	response.SetExposeInternalErrors(cfg.Redact.ExposeInternalErrors)
+
+	// телеметрия инициализируется до логгера: в нее пересылаются логи
+	sentryEnabled, err := telemetry.NewSentry(cfg.Sentry, cfg.Env, redactor)
+	if err != nil {
+		stdlog.Fatalf("failed to init sentry: %s", err)
+	}
+
+	newRelicApp, err := telemetry.NewNewRelic(cfg.NewRelic)
+	if err != nil {
+		stdlog.Fatalf("failed to init new relic: %s", err)
+	}
+
+	logSinks, err := logger.NewSinks(cfg.LogSinks, sentryEnabled, newRelicApp)
+	if err != nil {
+		stdlog.Fatalf("failed to init log sinks: %s", err)
+	}
+
+	logSampler, err := logger.NewSampler(cfg.LogSampling)
+	if err != nil {
+		stdlog.Fatalf("failed to init log sampling: %s", err)
+	}
+
+	log, logLevels, err := logger.New(cfg.Env, cfg.Log, redactor, logSampler, logSinks.List()...)
+	if err != nil {
+		stdlog.Fatalf("failed to init logger: %s", err)
+	}
+
+	// logger.FromContext вне запроса возвращает логгер по умолчанию
+	slog.SetDefault(log)
 
 	log.Info("go new relic sentry prometheus started", slog.String("env", cfg.Env))
 
+	// Компоненты останавливаются в порядке, обратном регистрации:
+	// сервер, фоновые задачи, БД, телеметрия, приемники логов.
+	// Приемники закрываются последними и получают логи остановки остальных
+	app := lifecycle.New(cfg.Lifecycle, log)
+	app.Append(lifecycle.Hook{Name: "log_sinks", OnStop: logSinks.Close})
+
+	if statsd != nil {
+		app.Append(lifecycle.Hook{Name: "statsd", OnStop: statsd.Close})
+	}
+	if sentryEnabled {
+		app.Append(lifecycle.Hook{Name: "sentry", OnStop: telemetry.FlushSentry})
+	}
+	if newRelicApp != nil {
+		app.Append(lifecycle.Hook{Name: "new_relic", OnStop: func(ctx context.Context) error {
+			return telemetry.ShutdownNewRelic(ctx, newRelicApp)
+		}})
+	}
+	if logSampler != nil {
+		app.Append(lifecycle.Worker("log_sampling", logSampler.Run))
+	}
+
+	app.Append(lifecycle.Worker("log_level_reload", func(ctx context.Context) {
+		logLevels.ReloadOnSignal(ctx, log, logger.DefaultLevel(cfg.Env), func() (config.Log, error) {
+			reloaded, err := config.Reload()
+			if err != nil {
+				return config.Log{}, err
+			}
+			return reloaded.Log, nil
+		})
+	}))
+
 	db, err := database.NewDatabase(cfg.Database)
 	if err != nil {
 		log.Error(err.Error())
 		os.Exit(1)
 	}
+	app.Append(lifecycle.Closer("database", db.Close))
+
+	if err := metrics.RegisterDBStats(db.DB.DB, cfg.Database.DBName); err != nil {
+		log.Error("failed to register database metrics", sl.Err(err))
+		os.Exit(1)
+	}
+
+	webhookRepo := postgres.NewWebhookRepository(db.DB)
+	dispatcher := webhookCase.NewDispatcher(webhookRepo, httpclient.New("webhook", cfg.Webhook.Timeout, log), cfg.Webhook, log)
+	webhookUseCase := webhookCase.NewWebhookUseCase(webhookRepo, dispatcher, log)
+	webhookHandler := webhookHandler.NewWebhookHandler(webhookUseCase)
+
+	userDBRepo := postgres.NewUserRepository(db.DB)
+	var userRepo domain.UserRepository = userDBRepo
+	var userBulkRepo domain.UserBulkRepository = postgres.NewUserBulkRepository(db.DB)
+	if cfg.Cache.Enabled {
+		lru, err := cache.NewLRU(cfg.Cache.Size)
+		if err != nil {
+			log.Error("failed to init user cache", sl.Err(err))
+			os.Exit(1)
+		}
+		userCache := cache.NewUserRepository(userRepo, lru, cfg.Cache, log)
+		userRepo = userCache
+		// импорт идет мимо userRepository, поэтому сбрасывает тот же кэш
+		userBulkRepo = cache.NewUserBulkRepository(userBulkRepo, userCache)
+	}
+	auditRepo := postgres.NewAuditRepository(db.DB)
+	var auditUseCase domain.AuditUseCase = auditCase.NewAuditUseCase(auditRepo, log)
 
-	userRepo := postgres.NewUserRepository(db.DB)
-	userUseCase := userCase.NewUserUseCase(userRepo)
+	app.Append(lifecycle.Worker("users_gauge", instrument.NewUserCounter(userBulkRepo, cfg.Metrics.UsersRefreshInterval, log).Run))
+
+	var userBulkUseCase domain.UserBulkUseCase = instrument.NewUserBulkUseCase(userCase.NewUserBulkUseCase(userBulkRepo, log))
+	userBulkUseCase = webhookCase.NewUserBulkEventsUseCase(userBulkUseCase, webhookUseCase, log)
+	userBulkUseCase = auditCase.NewUserBulkUseCase(userBulkUseCase, auditRepo, log)
+
+	var userUseCase domain.UserUseCase = instrument.NewUserUseCase(userCase.NewUserUseCase(userRepo))
+	userUseCase = webhookCase.NewUserEventsUseCase(userUseCase, webhookUseCase, log)
+	userUseCase = auditCase.NewUserUseCase(userUseCase, userDBRepo, auditRepo, log)
+	if cfg.Auth.Enabled {
+		policy := authz.DefaultPolicy()
+		if len(cfg.Authz.Roles) > 0 {
+			if policy, err = authz.NewPolicy(cfg.Authz.Roles); err != nil {
+				log.Error("invalid authz policy", sl.Err(err))
+				os.Exit(1)
+			}
+		}
+		userUseCase = authz.NewUserUseCase(userUseCase, policy, log)
+		auditUseCase = authz.NewAuditUseCase(auditUseCase, policy, log)
+		userBulkUseCase = authz.NewUserBulkUseCase(userBulkUseCase, policy, log)
+	}
+	bulkHandler := userHandler.NewBulkHandler(userBulkUseCase, cfg.Bulk)
 	userHandler := userHandler.NewUserHandler(userUseCase, log)
+	auditHandler := auditHandler.NewAuditHandler(auditUseCase, log)
+
+	var idempotencyRepo domain.IdempotencyRepository
+	switch cfg.Idempotency.Store {
+	case "memory":
+		idempotencyRepo = memory.NewIdempotencyRepository()
+	default:
+		idempotencyRepo = postgres.NewIdempotencyRepository(db.DB)
+	}
+
+	var apiKeyRepo domain.APIKeyRepository
+	switch cfg.Auth.APIKeyStore {
+	case "postgres":
+		apiKeyRepo = postgres.NewAPIKeyRepository(db.DB)
+	default:
+		apiKeyRepo = auth.NewStaticKeyRepository(cfg.Auth.APIKeys)
+	}
 
 	router := chi.NewRouter()
 
 	// Добавляем middleware
-	router.Use(middleware.RequestID)
+	router.Use(requestid.New())
+	router.Use(reqlog.New(log))
 	router.Use(middleware.RealIP)
 	router.Use(middleware.Logger)
-	router.Use(middleware.Recoverer)
 	router.Use(mvLogger.New(log))
-	// router.Use(response.ErrorHandlerMiddleware(log))
+	router.Use(tracing.New(sentryEnabled, newRelicApp))
+	router.Use(httpmetrics.New())
+	router.Use(recoverer.New(log, newRelicApp, redactor))
+	// Общее хранилище: бакеты PreAuth и New различаются областью
+	rateLimitStore := ratelimit.NewMemoryStore()
+	preAuthLimiter, err := ratelimit.PreAuth(log, rateLimitStore, cfg.RateLimit)
+	if err != nil {
+		log.Error("failed to init rate limiting", sl.Err(err))
+		os.Exit(1)
+	}
+	rateLimiter, err := ratelimit.New(log, rateLimitStore, cfg.RateLimit)
+	if err != nil {
+		log.Error("failed to init rate limiting", sl.Err(err))
+		os.Exit(1)
+	}
+
+	// PreAuth стоит перед auth.New, чтобы ограничивать и попытки с неверными
+	// учетными данными; New — после, чтобы различать клиентов по ключу
+	router.Use(preAuthLimiter)
+	if cfg.Auth.Enabled {
+		jwtAuthenticator, err := auth.NewJWTAuthenticator(cfg.Auth.JWT)
+		if err != nil {
+			log.Error("failed to init jwt authentication", sl.Err(err))
+			os.Exit(1)
+		}
+
+		router.Use(auth.New(log, auth.NewAPIKeyAuthenticator(apiKeyRepo), jwtAuthenticator))
+	}
+	router.Use(rateLimiter)
 	// router.Use(response.LoggingMiddleware(log))
 	// router.Use(response.StatusLoggingMiddleware(log))
 
 	router.Route("/api/v1", func(r chi.Router) {
+		if cfg.Auth.Enabled {
+			r.Use(auth.Required)
+		}
+
+		r.Post("/users:bulk", bulkHandler.ImportUsers)
+		r.Get("/users:export", bulkHandler.ExportUsers)
+
 		r.Route("/users", func(r chi.Router) {
-			r.Post("/", userHandler.CreateUser)
+			r.Get("/", bulkHandler.ListUsers)
+			r.With(idempotency.New(log, idempotencyRepo, cfg.Idempotency)).Post("/", userHandler.CreateUser)
 			r.Get("/{id}", userHandler.GetUserByID)
 			r.Put("/{id}", userHandler.UpdateUser)
 			r.Delete("/{id}", userHandler.DeleteUser)
+			r.Get("/{id}/history", auditHandler.UserHistory)
 		})
-	})
 
-	log.Info("starting server", slog.String("address", cfg.Address))
+		r.Route("/webhooks", func(r chi.Router) {
+			if cfg.Auth.Enabled {
+				r.Use(auth.RequireRole(domain.RoleAdmin))
+			}
 
-	done := make(chan os.Signal, 1)
-	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+			r.Post("/", webhookHandler.CreateSubscription)
+			r.Get("/", webhookHandler.ListSubscriptions)
+			r.Get("/{id}", webhookHandler.GetSubscriptionByID)
+			r.Put("/{id}", webhookHandler.UpdateSubscription)
+			r.Delete("/{id}", webhookHandler.DeleteSubscription)
+			r.Get("/{id}/deliveries", webhookHandler.ListDeliveries)
+			r.Post("/{id}/deliveries/{deliveryID}/retry", webhookHandler.RetryDelivery)
+		})
+	})
 
 	srv := &http.Server{
 		Addr:         cfg.Address,
@@ -71,33 +273,32 @@ func main() {
 		IdleTimeout:  cfg.HTTPServer.IdleTimeout,
 	}
 
-	go func() {
-		if err := srv.ListenAndServe(); err != nil {
-			log.Error("failed to start server")
-		}
-	}()
-
-	log.Info("server started")
-
-	<-done
-	log.Info("stopping server")
+	app.Append(lifecycle.Worker("webhook_dispatcher", dispatcher.Run))
+	app.Append(lifecycle.Worker("idempotency_cleanup", func(ctx context.Context) {
+		idempotency.Cleanup(ctx, log, idempotencyRepo, cfg.Idempotency.CleanupInterval)
+	}))
+	// служебный сервер останавливается после публичного, чтобы readiness
+	// и метрики оставались доступны во время остановки
+	adminSrv := &http.Server{
+		Addr:         cfg.Admin.Address,
+		Handler:      adminHandler.NewRouter(cfg, app.ReadyHandler, logLevels, log),
+		ReadTimeout:  cfg.Admin.ReadTimeout,
+		WriteTimeout: cfg.Admin.WriteTimeout,
+	}
+	app.Append(app.Server("admin", adminSrv))
+	app.Append(app.Server("http", srv))
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	if err := srv.Shutdown(ctx); err != nil {
-		log.Error("failed to stop server", sl.Err(err))
+	go func() {
+		<-ctx.Done()
+		// повторный сигнал во время остановки завершает процесс сразу
+		stop()
+	}()
 
-		return
+	if err := app.Run(ctx); err != nil {
+		log.Error("application stopped with error", sl.Err(err))
+		os.Exit(1)
 	}
-
-	log.Info("server stopped")
-
-	// // Добавляем health check endpoint
-	// router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-	// 	response.SendOK(w, r, "Service is healthy", map[string]string{
-	// 		"status": "ok",
-	// 		"time":   time.Now().Format(time.RFC3339),
-	// 	})
-	// })
 }
@@ -0,0 +1,79 @@
+// purge удаляет истекшие ключи идемпотентности и отправляет метрики
+// запуска в Pushgateway или по remote_write (metrics.push в конфигурации).
+// Предназначен для запуска по расписанию, например из cron.
+//
+//	go run ./cmd/purge --config=config/local.yaml
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/config"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/infrastructure/database"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/logger/sl"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/metrics"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/repository/postgres"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func main() {
+	cfg := config.MustLoad()
+	log := slog.New(slog.NewJSONHandler(os.Stdout, nil)).With(slog.String("job", "purge"))
+
+	job := metrics.NewJobMetrics("purge", cfg.Metrics.Push)
+	deleted := job.Factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "purge_deleted_rows_total",
+		Help: "Number of expired rows deleted by the purge job.",
+	}, []string{"table"})
+	duration := job.Factory.NewHistogram(prometheus.HistogramOpts{
+		Name: "purge_duration_seconds",
+		Help: "Duration of the purge job run.",
+	})
+	lastSuccess := job.Factory.NewGauge(prometheus.GaugeOpts{
+		Name: "purge_last_success_timestamp_seconds",
+		Help: "Unix time of the last successful purge job run.",
+	})
+
+	start := time.Now()
+	n, err := run(context.Background(), cfg.Database, start)
+	duration.Observe(time.Since(start).Seconds())
+
+	// метрики отправляются и при ошибке: по отсутствию роста
+	// last_success срабатывает алерт
+	if err == nil {
+		deleted.WithLabelValues("idempotency_keys").Add(float64(n))
+		lastSuccess.SetToCurrentTime()
+		log.Info("expired idempotency keys deleted", slog.Int64("count", n))
+	} else {
+		log.Error("failed to purge expired idempotency keys", sl.Err(err))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if pushErr := job.Push(ctx); pushErr != nil {
+		log.Error("failed to push job metrics", sl.Err(pushErr))
+	}
+
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, cfg config.Database, now time.Time) (int64, error) {
+	db, err := database.NewDatabase(cfg)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	n, appErr := postgres.NewIdempotencyRepository(db.DB).DeleteExpired(ctx, now)
+	if appErr != nil {
+		return 0, errors.New(appErr.Message)
+	}
+	return n, nil
+}
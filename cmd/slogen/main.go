@@ -0,0 +1,54 @@
+// slogen генерирует правила записи и алерты Prometheus для SLO из slo.Default.
+//
+//	go run ./cmd/slogen                 записать docker/rules/slo.yml
+//	go run ./cmd/slogen -check          проверить, что файл актуален
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/slo"
+)
+
+func main() {
+	out := flag.String("out", "docker/rules/slo.yml", "path to the generated rule file")
+	check := flag.Bool("check", false, "fail if the rule file is out of date instead of writing it")
+	flag.Parse()
+
+	if err := run(*out, *check); err != nil {
+		fmt.Fprintln(os.Stderr, "slogen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(out string, check bool) error {
+	rules, err := slo.Rules(slo.Default)
+	if err != nil {
+		return err
+	}
+
+	data, err := slo.Marshal(rules)
+	if err != nil {
+		return err
+	}
+
+	if check {
+		current, err := os.ReadFile(out)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(current, data) {
+			return fmt.Errorf("%s is out of date, run go run ./cmd/slogen", out)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(out), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(out, data, 0o644)
+}
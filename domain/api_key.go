@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"time"
+)
+
+// APIKey статический ключ доступа. Хранится только SHA-256 хэш ключа
+type APIKey struct {
+	ID        uint       `json:"id"`
+	Name      string     `json:"name"`
+	Hash      string     `json:"-"`
+	Roles     []string   `json:"roles"`
+	UserID    uint       `json:"user_id,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+type APIKeyRepository interface {
+	GetAPIKeyByHash(hash string) (APIKey, *AppError)
+}
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+type AuditOperation string
+
+const (
+	AuditCreate AuditOperation = "create"
+	AuditUpdate AuditOperation = "update"
+	AuditDelete AuditOperation = "delete"
+)
+
+// AuditEntry запись журнала изменений. Diff содержит только измененные поля
+// в виде {"поле": {"old": ..., "new": ...}}
+type AuditEntry struct {
+	ID         uint            `json:"id"`
+	Actor      string          `json:"actor"`
+	RequestID  string          `json:"request_id,omitempty"`
+	Operation  AuditOperation  `json:"operation"`
+	Resource   string          `json:"resource"`
+	ResourceID uint            `json:"resource_id"`
+	Diff       json.RawMessage `json:"diff"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+type AuditRepository interface {
+	CreateEntry(ctx context.Context, entry AuditEntry) (AuditEntry, *AppError)
+	ListEntries(ctx context.Context, resource string, resourceID uint, limit, offset int) ([]AuditEntry, *AppError)
+}
+
+type AuditUseCase interface {
+	UserHistory(ctx context.Context, userID uint, limit, offset int) ([]AuditEntry, *AppError)
+}
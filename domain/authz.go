@@ -0,0 +1,29 @@
+package domain
+
+type Role string
+
+const (
+	RoleReader Role = "reader"
+	RoleEditor Role = "editor"
+	RoleAdmin  Role = "admin"
+)
+
+type Permission string
+
+const (
+	PermissionCreate Permission = "create"
+	PermissionRead   Permission = "read"
+	PermissionUpdate Permission = "update"
+	PermissionDelete Permission = "delete"
+	PermissionList   Permission = "list"
+)
+
+// HasRole проверяет, есть ли у вызывающего роль
+func (p Principal) HasRole(role Role) bool {
+	for _, r := range p.Roles {
+		if Role(r) == role {
+			return true
+		}
+	}
+	return false
+}
@@ -7,6 +7,8 @@ import (
 type AppError struct {
 	Code    int    `json:",omitempty"`
 	Message string `json:"message"`
+	// Field поле, не прошедшее валидацию
+	Field string `json:"-"`
 }
 
 func (e AppError) AsMessageError() *AppError {
@@ -27,10 +29,18 @@ func NewBadRequestError(message string) *AppError {
 	return &AppError{Code: http.StatusBadRequest, Message: message}
 }
 
+func NewForbiddenError(message string) *AppError {
+	return &AppError{Code: http.StatusForbidden, Message: message}
+}
+
 func NewUserAlreadyExistError(message string) *AppError {
 	return &AppError{Code: http.StatusConflict, Message: message}
 }
 
 func NewValidationError(message string) *AppError {
 	return &AppError{Code: http.StatusBadRequest, Message: message}
-}
\ No newline at end of file
+}
+
+func NewFieldValidationError(field, message string) *AppError {
+	return &AppError{Code: http.StatusBadRequest, Message: message, Field: field}
+}
@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// IdempotencyRecord сохраненный результат запроса с заголовком Idempotency-Key
+type IdempotencyRecord struct {
+	Key         string
+	Fingerprint string
+	// Token выдается при резервировании. Complete и Delete меняют запись,
+	// только если токен совпадает: ключ, зарезервированный заново после
+	// истечения LockTimeout, не перезаписывается прежним запросом
+	Token      string
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+}
+
+// Completed возвращает false, пока исходный запрос еще обрабатывается
+func (r IdempotencyRecord) Completed() bool {
+	return r.StatusCode != 0
+}
+
+type IdempotencyRepository interface {
+	// Reserve создает запись для нового ключа и возвращает true.
+	// Если для ключа уже есть неистекшая запись, возвращает ее и false
+	Reserve(ctx context.Context, record IdempotencyRecord) (IdempotencyRecord, bool, *AppError)
+	// Complete сохраняет ответ и новый срок хранения записи.
+	// Если резерв с record.Token уже не действует, возвращает ошибку с кодом 404
+	Complete(ctx context.Context, record IdempotencyRecord) *AppError
+	// Delete освобождает ключ, зарезервированный с token
+	Delete(ctx context.Context, key, token string) *AppError
+	DeleteExpired(ctx context.Context, now time.Time) (int64, *AppError)
+}
@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Способы аутентификации
+const (
+	AuthMethodAPIKey = "api_key"
+	AuthMethodJWT    = "jwt"
+)
+
+// Principal аутентифицированный вызывающий
+type Principal struct {
+	ID     string   `json:"id"`
+	Name   string   `json:"name,omitempty"`
+	Method string   `json:"method"`
+	Roles  []string `json:"roles,omitempty"`
+	// UserID пользователь, от имени которого выполняется вызов, 0 для сервисных ключей
+	UserID uint `json:"user_id,omitempty"`
+}
+
+// LogValue позволяет передавать Principal в slog как одно значение
+func (p Principal) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("id", p.ID),
+		slog.String("method", p.Method),
+		slog.Any("roles", p.Roles),
+	)
+}
+
+type principalKey struct{}
+
+// ContextWithPrincipal сохраняет Principal в контексте
+func ContextWithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext возвращает Principal из контекста
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"context"
 	"time"
 )
 
@@ -11,16 +12,28 @@ type User struct {
 	CreatedDate time.Time `json:"created_date"`
 }
 
+// Validate правила, общие для создания, изменения и импорта пользователей.
+// Ошибка указывает поле, не прошедшее проверку
+func (u User) Validate() *AppError {
+	if u.Name == "" {
+		return NewFieldValidationError("name", "The name should not be empty.")
+	}
+	if u.Age < 0 {
+		return NewFieldValidationError("age", "The age should not be negative.")
+	}
+	return nil
+}
+
 type UserRepository interface {
-	CreateUser(user User) (User, *AppError)
-	GetUserById(id uint) (User, *AppError)
-	UpdateUser(user User) (User, *AppError)
-	DeleteUserById(id uint) *AppError
+	CreateUser(ctx context.Context, user User) (User, *AppError)
+	GetUserById(ctx context.Context, id uint) (User, *AppError)
+	UpdateUser(ctx context.Context, user User) (User, *AppError)
+	DeleteUserById(ctx context.Context, id uint) *AppError
 }
 
 type UserUseCase interface {
-	CreateUser(user User) (User, *AppError)
-	GetUserById(id uint) (User, *AppError)
-	UpdateUser(user User) (User, *AppError)
-	DeleteUserById(id uint) *AppError
+	CreateUser(ctx context.Context, user User) (User, *AppError)
+	GetUserById(ctx context.Context, id uint) (User, *AppError)
+	UpdateUser(ctx context.Context, user User) (User, *AppError)
+	DeleteUserById(ctx context.Context, id uint) *AppError
 }
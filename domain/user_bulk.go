@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// UserFilter условия выборки пользователей. Пустые поля не ограничивают выборку
+type UserFilter struct {
+	Name          string
+	MinAge        *int
+	MaxAge        *int
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+}
+
+type BulkItemStatus string
+
+const (
+	BulkItemCreated BulkItemStatus = "created"
+	BulkItemFailed  BulkItemStatus = "failed"
+)
+
+// BulkItemResult результат импорта одной записи.
+// Index номер записи в переданном пакете, начиная с 0
+type BulkItemResult struct {
+	Index  int
+	Status BulkItemStatus
+	User   User
+	Err    *AppError
+}
+
+type UserBulkRepository interface {
+	// CopyUsers сохраняет пользователей одним пакетом и возвращает их с присвоенными ID
+	CopyUsers(ctx context.Context, users []User) ([]User, *AppError)
+	ListUsers(ctx context.Context, filter UserFilter, limit, offset int) ([]User, *AppError)
+	// StreamUsers вызывает fn для каждого пользователя, не загружая выборку в память
+	StreamUsers(ctx context.Context, filter UserFilter, fn func(User) error) *AppError
+	CountUsers(ctx context.Context, filter UserFilter) (int, *AppError)
+}
+
+type UserBulkUseCase interface {
+	ImportUsers(ctx context.Context, users []User) ([]BulkItemResult, *AppError)
+	ListUsers(ctx context.Context, filter UserFilter, limit, offset int) ([]User, *AppError)
+	ExportUsers(ctx context.Context, filter UserFilter, fn func(User) error) *AppError
+}
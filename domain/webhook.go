@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+type WebhookEvent string
+
+const (
+	EventUserCreated WebhookEvent = "user.created"
+	EventUserUpdated WebhookEvent = "user.updated"
+	EventUserDeleted WebhookEvent = "user.deleted"
+)
+
+// WebhookEvents список событий, на которые можно подписаться
+var WebhookEvents = []WebhookEvent{EventUserCreated, EventUserUpdated, EventUserDeleted}
+
+func (e WebhookEvent) IsValid() bool {
+	for _, known := range WebhookEvents {
+		if e == known {
+			return true
+		}
+	}
+	return false
+}
+
+type WebhookSubscription struct {
+	ID        uint           `json:"id"`
+	URL       string         `json:"url"`
+	Secret    string         `json:"-"`
+	Events    []WebhookEvent `json:"events"`
+	Active    bool           `json:"active"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// Matches проверяет, подписана ли подписка на событие
+func (s WebhookSubscription) Matches(event WebhookEvent) bool {
+	if !s.Active {
+		return false
+	}
+	for _, e := range s.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryRetrying  DeliveryStatus = "retrying"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryDead      DeliveryStatus = "dead"
+)
+
+type WebhookDelivery struct {
+	ID             uint            `json:"id"`
+	SubscriptionID uint            `json:"subscription_id"`
+	Event          WebhookEvent    `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         DeliveryStatus  `json:"status"`
+	Attempts       int             `json:"attempts"`
+	Retries        int             `json:"retries"`
+	ResponseCode   int             `json:"response_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	Duration       time.Duration   `json:"duration_ns"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+
+	// AttemptsBeforeRetry значение Attempts при последнем ручном повторе
+	AttemptsBeforeRetry int `json:"-"`
+}
+
+// CurrentAttempts попытки с последней постановки в очередь. По ним
+// считаются задержка и лимит попыток, а Attempts хранит всю историю
+func (d WebhookDelivery) CurrentAttempts() int {
+	return d.Attempts - d.AttemptsBeforeRetry
+}
+
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, sub WebhookSubscription) (WebhookSubscription, *AppError)
+	GetSubscriptionById(ctx context.Context, id uint) (WebhookSubscription, *AppError)
+	ListSubscriptions(ctx context.Context) ([]WebhookSubscription, *AppError)
+	UpdateSubscription(ctx context.Context, sub WebhookSubscription) (WebhookSubscription, *AppError)
+	DeleteSubscriptionById(ctx context.Context, id uint) *AppError
+
+	CreateDelivery(ctx context.Context, delivery WebhookDelivery) (WebhookDelivery, *AppError)
+	GetDeliveryById(ctx context.Context, id uint) (WebhookDelivery, *AppError)
+	UpdateDelivery(ctx context.Context, delivery WebhookDelivery) (WebhookDelivery, *AppError)
+	ListDeliveries(ctx context.Context, subscriptionID uint, limit, offset int) ([]WebhookDelivery, *AppError)
+	// ClaimDueDeliveries атомарно забирает доставки, время которых подошло,
+	// и откладывает их следующую попытку до leaseUntil. Другие экземпляры
+	// не получат те же доставки, а брошенные упавшим процессом вернутся
+	// в очередь после leaseUntil
+	ClaimDueDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]WebhookDelivery, *AppError)
+}
+
+type WebhookUseCase interface {
+	CreateSubscription(ctx context.Context, sub WebhookSubscription) (WebhookSubscription, *AppError)
+	GetSubscriptionById(ctx context.Context, id uint) (WebhookSubscription, *AppError)
+	ListSubscriptions(ctx context.Context) ([]WebhookSubscription, *AppError)
+	UpdateSubscription(ctx context.Context, sub WebhookSubscription) (WebhookSubscription, *AppError)
+	DeleteSubscriptionById(ctx context.Context, id uint) *AppError
+	ListDeliveries(ctx context.Context, subscriptionID uint, limit, offset int) ([]WebhookDelivery, *AppError)
+	RetryDelivery(ctx context.Context, subscriptionID, deliveryID uint) (WebhookDelivery, *AppError)
+	// Publish создает доставки события для каждого объекта data
+	Publish(ctx context.Context, event WebhookEvent, data ...any) *AppError
+}
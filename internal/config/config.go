@@ -0,0 +1,340 @@
+package config
+
+import (
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/ilyakaznacheev/cleanenv"
+)
+
+// Config конфигурация приложения
+type Config struct {
+	Env         string `yaml:"env" env:"ENV" env-default:"local"`
+	HTTPServer  `yaml:"http_server"`
+	Database    Database    `yaml:"database"`
+	Webhook     Webhook     `yaml:"webhook"`
+	Idempotency Idempotency `yaml:"idempotency"`
+	RateLimit   RateLimit   `yaml:"rate_limit"`
+	Auth        Auth        `yaml:"auth"`
+	Authz       Authz       `yaml:"authz"`
+	Cache       Cache       `yaml:"cache"`
+	Bulk        Bulk        `yaml:"bulk"`
+	Lifecycle   Lifecycle   `yaml:"lifecycle"`
+	Sentry      Sentry      `yaml:"sentry"`
+	NewRelic    NewRelic    `yaml:"new_relic"`
+	Admin       Admin       `yaml:"admin"`
+	Log         Log         `yaml:"log"`
+	LogSinks    LogSinks    `yaml:"log_sinks"`
+	LogSampling LogSampling `yaml:"log_sampling"`
+	Redact      Redact      `yaml:"redact"`
+	Metrics     Metrics     `yaml:"metrics"`
+}
+
+// HTTPServer настройки публичного HTTP сервера
+type HTTPServer struct {
+	Address     string        `yaml:"address" env:"HTTP_ADDRESS" env-default:"localhost:8087"`
+	Timeout     time.Duration `yaml:"timeout" env-default:"4s"`
+	IdleTimeout time.Duration `yaml:"idle_timeout" env-default:"60s"`
+}
+
+// Database настройки подключения к БД
+type Database struct {
+	Type                 string        `yaml:"type" env:"DB_TYPE" env-default:"postgres"`
+	Host                 string        `yaml:"host" env:"DB_HOST" env-default:"localhost"`
+	Port                 string        `yaml:"port" env:"DB_PORT" env-default:"5432"`
+	Username             string        `yaml:"username" env:"DB_USERNAME" env-default:"postgres"`
+	Password             string        `yaml:"password" env:"DB_PASSWORD" env-default:"postgres" secret:"true"`
+	DBName               string        `yaml:"db_name" env:"DB_NAME" env-default:"postgres"`
+	SSLMode              string        `yaml:"ssl_mode" env:"DB_SSL_MODE" env-default:"disable"`
+	MaxDBConnections     int           `yaml:"max_connections" env-default:"10"`
+	MaxDBIdleConnections int           `yaml:"max_idle_connections" env-default:"5"`
+	MaxDBLifetime        time.Duration `yaml:"max_lifetime" env-default:"5m"`
+}
+
+// Webhook настройки доставки вебхуков. Lease — на сколько доставка
+// закрепляется за экземпляром, забравшим ее; должен превышать Timeout
+type Webhook struct {
+	Workers      int           `yaml:"workers" env-default:"4"`
+	Timeout      time.Duration `yaml:"timeout" env-default:"5s"`
+	MaxAttempts  int           `yaml:"max_attempts" env-default:"8"`
+	BackoffBase  time.Duration `yaml:"backoff_base" env-default:"1s"`
+	BackoffMax   time.Duration `yaml:"backoff_max" env-default:"10m"`
+	PollInterval time.Duration `yaml:"poll_interval" env-default:"2s"`
+	BatchSize    int           `yaml:"batch_size" env-default:"50"`
+	Lease        time.Duration `yaml:"lease" env-default:"1m"`
+}
+
+// Idempotency настройки хранения ключей идемпотентности.
+// LockTimeout сколько ключ остается занятым незавершенным запросом:
+// запись, брошенная упавшим процессом, освобождается по его истечении
+type Idempotency struct {
+	Store           string        `yaml:"store" env:"IDEMPOTENCY_STORE" env-default:"postgres"`
+	TTL             time.Duration `yaml:"ttl" env-default:"24h"`
+	LockTimeout     time.Duration `yaml:"lock_timeout" env-default:"1m"`
+	CleanupInterval time.Duration `yaml:"cleanup_interval" env-default:"10m"`
+	MaxBodyBytes    int64         `yaml:"max_body_bytes" env-default:"1048576"`
+}
+
+// RateLimit настройки ограничения частоты запросов.
+// KeyBy определяет, по чему считаются запросы: ip, api_key или user.
+// PreAuth ограничивает запросы по IP до аутентификации, в том числе
+// попытки с неверными учетными данными
+type RateLimit struct {
+	Enabled bool             `yaml:"enabled" env:"RATE_LIMIT_ENABLED" env-default:"true"`
+	KeyBy   string           `yaml:"key_by" env-default:"ip"`
+	Default RateLimitPolicy  `yaml:"default"`
+	PreAuth RateLimitPolicy  `yaml:"pre_auth"`
+	Routes  []RouteRateLimit `yaml:"routes"`
+}
+
+// RateLimitPolicy допускает Requests запросов за Period и всплески до Burst
+type RateLimitPolicy struct {
+	Requests int           `yaml:"requests" env-default:"100"`
+	Period   time.Duration `yaml:"period" env-default:"1m"`
+	Burst    int           `yaml:"burst" env-default:"20"`
+}
+
+// RouteRateLimit политика для конкретного маршрута chi, например "POST /api/v1/users/"
+type RouteRateLimit struct {
+	Method  string          `yaml:"method"`
+	Pattern string          `yaml:"pattern"`
+	KeyBy   string          `yaml:"key_by"`
+	Policy  RateLimitPolicy `yaml:"policy"`
+}
+
+// Auth настройки аутентификации /api/v1.
+// APIKeyStore выбирает источник API ключей: config или postgres
+type Auth struct {
+	Enabled     bool     `yaml:"enabled" env:"AUTH_ENABLED" env-default:"true"`
+	APIKeyStore string   `yaml:"api_key_store" env-default:"config"`
+	APIKeys     []APIKey `yaml:"api_keys"`
+	JWT         JWT      `yaml:"jwt"`
+}
+
+// APIKey статический ключ, Hash - hex SHA-256 от значения ключа
+type APIKey struct {
+	Name   string   `yaml:"name"`
+	Hash   string   `yaml:"hash" secret:"true"`
+	Roles  []string `yaml:"roles"`
+	UserID uint     `yaml:"user_id"`
+}
+
+// JWT настройки проверки bearer токенов: HMAC секрет и/или JWKS файл
+type JWT struct {
+	Secret   string        `yaml:"secret" env:"JWT_SECRET" secret:"true"`
+	JWKSFile string        `yaml:"jwks_file" env:"JWT_JWKS_FILE"`
+	Issuer   string        `yaml:"issuer"`
+	Audience string        `yaml:"audience"`
+	Leeway   time.Duration `yaml:"leeway" env-default:"30s"`
+}
+
+// Authz роли и разрешенные им операции (create, read, update, delete, list).
+// Если роли не заданы, используется политика по умолчанию
+type Authz struct {
+	Roles map[string][]string `yaml:"roles"`
+}
+
+// Cache настройки кэша пользователей. NegativeTTL задает, сколько
+// хранится отсутствие пользователя; 0 отключает негативное кэширование
+type Cache struct {
+	Enabled     bool          `yaml:"enabled" env:"CACHE_ENABLED" env-default:"true"`
+	Size        int           `yaml:"size" env-default:"10000"`
+	TTL         time.Duration `yaml:"ttl" env-default:"1m"`
+	NegativeTTL time.Duration `yaml:"negative_ttl" env-default:"10s"`
+}
+
+// Bulk ограничения импорта пользователей. Записи сверх MaxItems
+// отклоняются по одной, тело больше MaxBodyBytes не дочитывается
+type Bulk struct {
+	BatchSize    int   `yaml:"batch_size" env-default:"500"`
+	MaxItems     int   `yaml:"max_items" env-default:"10000"`
+	MaxBodyBytes int64 `yaml:"max_body_bytes" env-default:"33554432"`
+	// FlushEvery через сколько строк экспорта данные отправляются клиенту
+	FlushEvery int `yaml:"flush_every" env-default:"100"`
+	// ReadTimeout срок чтения тела импорта вместо общего таймаута сервера
+	ReadTimeout time.Duration `yaml:"read_timeout" env-default:"10m"`
+}
+
+// Lifecycle ShutdownTimeout общий бюджет остановки, включая DrainDelay —
+// паузу между отказом readiness и закрытием слушателей
+type Lifecycle struct {
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" env-default:"30s"`
+	DrainDelay      time.Duration `yaml:"drain_delay" env:"SHUTDOWN_DRAIN_DELAY" env-default:"5s"`
+}
+
+// Sentry отправка ошибок отключена, если DSN не задан
+type Sentry struct {
+	DSN              string  `yaml:"dsn" env:"SENTRY_DSN" secret:"true"`
+	TracesSampleRate float64 `yaml:"traces_sample_rate" env-default:"1.0"`
+}
+
+// NewRelic агент отключен, если лицензионный ключ не задан
+type NewRelic struct {
+	AppName string `yaml:"app_name" env:"NEW_RELIC_APP_NAME" env-default:"golang-new-relic-sentry-prometheus"`
+	License string `yaml:"license" env:"NEW_RELIC_LICENSE_KEY" secret:"true"`
+}
+
+// Admin служебный HTTP сервер: метрики, проверки состояния, pprof.
+// WriteTimeout должен превышать длительность снятия профиля
+type Admin struct {
+	Address      string        `yaml:"address" env:"ADMIN_ADDRESS" env-default:"localhost:8088"`
+	ReadTimeout  time.Duration `yaml:"read_timeout" env-default:"5s"`
+	WriteTimeout time.Duration `yaml:"write_timeout" env-default:"60s"`
+}
+
+// Log уровни логирования: общий и для отдельных компонентов
+// (значение атрибута component). Пустой Level — уровень по умолчанию
+// для окружения. Секция перечитывается по SIGHUP
+type Log struct {
+	Level      string            `yaml:"level" env:"LOG_LEVEL"`
+	Components map[string]string `yaml:"components"`
+}
+
+// LogSinks приемники логов помимо stdout. Level приемника применяется
+// поверх уровней из Log; пустой Level не ограничивает. BufferSize —
+// число записей, ожидающих отправки в каждый приемник, сверх него записи
+// отбрасываются
+type LogSinks struct {
+	BufferSize int             `yaml:"buffer_size" env-default:"1024"`
+	File       LogFileSink     `yaml:"file"`
+	Sentry     LogSentrySink   `yaml:"sentry"`
+	NewRelic   LogNewRelicSink `yaml:"new_relic"`
+}
+
+// LogFileSink JSON файл с ротацией по размеру
+type LogFileSink struct {
+	Enabled    bool   `yaml:"enabled" env:"LOG_FILE_ENABLED"`
+	Level      string `yaml:"level"`
+	Path       string `yaml:"path" env:"LOG_FILE_PATH" env-default:"logs/app.log"`
+	MaxSizeMB  int    `yaml:"max_size_mb" env-default:"100"`
+	MaxBackups int    `yaml:"max_backups" env-default:"5"`
+	MaxAgeDays int    `yaml:"max_age_days" env-default:"30"`
+	Compress   bool   `yaml:"compress" env-default:"true"`
+}
+
+// LogSentrySink записи не ниже EventLevel становятся событиями Sentry,
+// записи от BreadcrumbLevel — breadcrumbs. Работает, только если задан Sentry.DSN
+type LogSentrySink struct {
+	Enabled         bool   `yaml:"enabled" env-default:"true"`
+	EventLevel      string `yaml:"event_level" env-default:"error"`
+	BreadcrumbLevel string `yaml:"breadcrumb_level" env-default:"info"`
+}
+
+// LogNewRelicSink пересылка логов в New Relic. Работает, только если задан NewRelic.License
+type LogNewRelicSink struct {
+	Enabled bool   `yaml:"enabled" env-default:"true"`
+	Level   string `yaml:"level" env-default:"info"`
+}
+
+// LogSampling ограничение потока одинаковых записей. Записи не ниже
+// DedupLevel с одинаковыми уровнем и сообщением сверх Burst за Interval
+// подавляются, по окончании интервала выводится сводка. Записи ниже
+// DedupLevel обычно различаются атрибутами (request_id) и не подавляются.
+// Ratios — доля пропускаемых записей для уровня (debug: 0.1), не указанные
+// уровни не семплируются
+type LogSampling struct {
+	Enabled    bool               `yaml:"enabled" env:"LOG_SAMPLING_ENABLED" env-default:"false"`
+	Interval   time.Duration      `yaml:"interval" env-default:"10s"`
+	Burst      int                `yaml:"burst" env-default:"5"`
+	DedupLevel string             `yaml:"dedup_level" env-default:"warn"`
+	Ratios     map[string]float64 `yaml:"ratios"`
+}
+
+// Redact скрытие чувствительных данных в логах, событиях Sentry и ответах.
+// Keys — ключи атрибутов, значения которых скрываются целиком.
+// ExposeInternalErrors возвращает клиенту детали ошибок 5xx, только для
+// локальной разработки
+type Redact struct {
+	Keys                 []string `yaml:"keys" env:"REDACT_KEYS" env-default:"password,token,authorization,email,secret,api_key,cookie,dsn"`
+	ExposeInternalErrors bool     `yaml:"expose_internal_errors" env:"EXPOSE_INTERNAL_ERRORS"`
+}
+
+// Metrics настройки метрик. Backend — prometheus или statsd.
+// UsersRefreshInterval — период обновления метрики общего числа пользователей
+type Metrics struct {
+	Backend              string        `yaml:"backend" env:"METRICS_BACKEND" env-default:"prometheus"`
+	StatsD               StatsD        `yaml:"statsd"`
+	UsersRefreshInterval time.Duration `yaml:"users_refresh_interval" env-default:"1m"`
+	Push                 MetricsPush   `yaml:"push"`
+}
+
+// StatsD агент StatsD/DogStatsD. Flavor — statsd или dogstatsd (метки
+// передаются тегами). Tags добавляются ко всем метрикам DogStatsD
+type StatsD struct {
+	Address       string            `yaml:"address" env:"STATSD_ADDRESS" env-default:"127.0.0.1:8125"`
+	Flavor        string            `yaml:"flavor" env:"STATSD_FLAVOR" env-default:"dogstatsd"`
+	Prefix        string            `yaml:"prefix" env-default:"golang_new_relic_sentry_prometheus."`
+	Tags          map[string]string `yaml:"tags"`
+	FlushInterval time.Duration     `yaml:"flush_interval" env-default:"1s"`
+	MaxPacketSize int               `yaml:"max_packet_size" env-default:"1432"`
+}
+
+// MetricsPush отправка метрик короткоживущих задач. Пустой адрес
+// отключает соответствующий способ. Неудачная отправка повторяется
+// Retries раз с удваивающейся задержкой, начиная с RetryBackoff.
+// Адреса могут содержать учетные данные и считаются секретами
+type MetricsPush struct {
+	PushgatewayURL string        `yaml:"pushgateway_url" env:"PUSHGATEWAY_URL" secret:"true"`
+	RemoteWriteURL string        `yaml:"remote_write_url" env:"REMOTE_WRITE_URL" secret:"true"`
+	Timeout        time.Duration `yaml:"timeout" env-default:"10s"`
+	Retries        int           `yaml:"retries" env-default:"3"`
+	RetryBackoff   time.Duration `yaml:"retry_backoff" env-default:"500ms"`
+}
+
+// MustLoad загружает конфигурацию из файла, путь к которому передан
+// флагом --config или переменной окружения CONFIG_PATH
+func MustLoad() *Config {
+	configPath := fetchConfigPath()
+	if configPath == "" {
+		log.Fatal("config path is not set")
+	}
+
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		log.Fatalf("config file does not exist: %s", configPath)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		log.Fatalf("cannot read config: %s", err)
+	}
+
+	loadedPath = configPath
+
+	return cfg
+}
+
+// loadedPath файл, из которого MustLoad загрузил конфигурацию
+var loadedPath string
+
+// Load читает конфигурацию из файла и переменных окружения
+func Load(path string) (*Config, error) {
+	var cfg Config
+
+	if err := cleanenv.ReadConfig(path, &cfg); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// Reload перечитывает файл, загруженный MustLoad
+func Reload() (*Config, error) {
+	return Load(loadedPath)
+}
+
+// fetchConfigPath возвращает путь к файлу конфигурации.
+// Приоритет: флаг > переменная окружения
+func fetchConfigPath() string {
+	var res string
+
+	flag.StringVar(&res, "config", "", "path to config file")
+	flag.Parse()
+
+	if res == "" {
+		res = os.Getenv("CONFIG_PATH")
+	}
+
+	return res
+}
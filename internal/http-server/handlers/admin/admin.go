@@ -0,0 +1,60 @@
+package admin
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/config"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/api/response"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/logger"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// NewRouter служебные эндпоинты, недоступные на публичном порту:
+// метрики, проверки состояния, pprof и текущая конфигурация
+func NewRouter(cfg *config.Config, ready http.HandlerFunc, levels *logger.Levels, log *slog.Logger) http.Handler {
+	logLevels := NewLogLevelHandler(levels, log)
+
+	router := chi.NewRouter()
+	router.Use(middleware.Recoverer)
+
+	// OpenMetrics нужен Prometheus, чтобы получать exemplars
+	router.Handle("/metrics", promhttp.InstrumentMetricHandler(
+		prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{EnableOpenMetrics: true}),
+	))
+	router.Get("/healthz", Healthz)
+	router.Get("/readyz", ready)
+	router.Get("/debug/config", Config(cfg, levels))
+	router.Get("/debug/log-level", logLevels.Get)
+	router.Put("/debug/log-level", logLevels.Update)
+	router.Mount("/debug", middleware.Profiler())
+
+	return router
+}
+
+// Healthz отвечает 200, пока процесс способен обслуживать запросы
+func Healthz(w http.ResponseWriter, r *http.Request) {
+	response.SendOK(w, r, "ok", nil)
+}
+
+// Config возвращает действующую конфигурацию без секретов. Уровни
+// логирования меняются без перезапуска (SIGHUP, /debug/log-level),
+// поэтому конфигурация собирается заново при каждом запросе, а раздел
+// log берется из levels
+func Config(cfg *config.Config, levels *logger.Levels) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		redacted := config.Redacted(cfg)
+
+		state := levels.State()
+		redacted["log"] = map[string]any{
+			"level":      state.Global,
+			"components": state.Components,
+		}
+
+		response.SendOK(w, r, "Effective configuration", redacted)
+	}
+}
@@ -0,0 +1,101 @@
+package admin
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/api/response"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/logger"
+	"github.com/go-chi/render"
+)
+
+// LogLevelRequest изменение уровней логирования. Duration делает общий
+// уровень временным; пустое значение компонента сбрасывает его переопределение
+type LogLevelRequest struct {
+	Level      string            `json:"level,omitempty"`
+	Duration   string            `json:"duration,omitempty"`
+	Components map[string]string `json:"components,omitempty"`
+}
+
+type LogLevelHandler struct {
+	levels *logger.Levels
+	log    *slog.Logger
+}
+
+func NewLogLevelHandler(levels *logger.Levels, log *slog.Logger) *LogLevelHandler {
+	return &LogLevelHandler{levels: levels, log: log}
+}
+
+// Get возвращает текущие уровни
+func (h *LogLevelHandler) Get(w http.ResponseWriter, r *http.Request) {
+	response.SendOK(w, r, "Log levels", h.levels.State())
+}
+
+// Update применяет изменения, только если все значения корректны
+func (h *LogLevelHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var req LogLevelRequest
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		response.SendBadRequest(w, r, "bad request")
+		return
+	}
+
+	var (
+		level    slog.Level
+		duration time.Duration
+		err      error
+	)
+
+	if req.Level != "" {
+		if level, err = logger.ParseLevel(req.Level); err != nil {
+			response.SendBadRequest(w, r, err.Error())
+			return
+		}
+	}
+
+	if req.Duration != "" {
+		if req.Level == "" {
+			response.SendBadRequest(w, r, "duration requires level")
+			return
+		}
+		if duration, err = time.ParseDuration(req.Duration); err != nil || duration <= 0 {
+			response.SendBadRequest(w, r, "invalid duration")
+			return
+		}
+	}
+
+	components := make(map[string]*slog.Level, len(req.Components))
+	for component, value := range req.Components {
+		if value == "" {
+			components[component] = nil
+			continue
+		}
+
+		componentLevel, err := logger.ParseLevel(value)
+		if err != nil {
+			response.SendBadRequest(w, r, component+": "+err.Error())
+			return
+		}
+		components[component] = &componentLevel
+	}
+
+	switch {
+	case duration > 0:
+		h.levels.SetGlobalFor(level, duration)
+	case req.Level != "":
+		h.levels.SetGlobal(level)
+	}
+
+	for component, componentLevel := range components {
+		if componentLevel == nil {
+			h.levels.ResetComponent(component)
+			continue
+		}
+		h.levels.SetComponent(component, *componentLevel)
+	}
+
+	state := h.levels.State()
+	h.log.Warn("log levels changed", slog.Any("levels", state), slog.String("remote_addr", r.RemoteAddr))
+
+	response.SendOK(w, r, "Log levels", state)
+}
@@ -0,0 +1,55 @@
+package audit
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/api/request"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/api/response"
+)
+
+type Handler struct {
+	auditUseCase domain.AuditUseCase
+	log          *slog.Logger
+}
+
+func NewAuditHandler(auditUseCase domain.AuditUseCase, log *slog.Logger) *Handler {
+	return &Handler{auditUseCase: auditUseCase, log: log}
+}
+
+// HistoryPage страница истории изменений.
+// NextOffset отсутствует на последней странице
+type HistoryPage struct {
+	Entries    []domain.AuditEntry `json:"entries"`
+	NextOffset *int                `json:"next_offset,omitempty"`
+}
+
+// UserHistory возвращает историю изменений пользователя, новые записи первыми
+func (h *Handler) UserHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := request.ParseID(r, "id")
+	if err != nil {
+		response.SendBadRequest(w, r, err.Error())
+		return
+	}
+
+	limit, offset, err := request.ParsePage(r)
+	if err != nil {
+		response.SendBadRequest(w, r, err.Error())
+		return
+	}
+
+	entries, appErr := h.auditUseCase.UserHistory(r.Context(), id, limit, offset)
+	if appErr != nil {
+		response.SendDomainError(w, r, appErr)
+		return
+	}
+
+	page := HistoryPage{Entries: entries}
+	if len(entries) == limit {
+		next := offset + limit
+		page.NextOffset = &next
+	}
+
+	response.SendOK(w, r, "User history", page)
+}
@@ -0,0 +1,464 @@
+package user
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/config"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/api/request"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/api/response"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/logger"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/logger/sl"
+)
+
+const (
+	contentTypeNDJSON = "application/x-ndjson"
+	contentTypeCSV    = "text/csv"
+
+	// maxNDJSONLine предельная длина одной строки NDJSON
+	maxNDJSONLine = 1 << 20
+)
+
+type BulkHandler struct {
+	bulkUseCase domain.UserBulkUseCase
+	cfg         config.Bulk
+}
+
+func NewBulkHandler(bulkUseCase domain.UserBulkUseCase, cfg config.Bulk) *BulkHandler {
+	return &BulkHandler{bulkUseCase: bulkUseCase, cfg: cfg}
+}
+
+// ImportItem запись входных данных импорта
+type ImportItem struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+// ImportItemResult результат импорта одной записи
+type ImportItemResult struct {
+	Index     int                   `json:"index"`
+	Status    domain.BulkItemStatus `json:"status"`
+	ID        uint                  `json:"id,omitempty"`
+	ErrorCode int                   `json:"error_code,omitempty"`
+	Error     string                `json:"error,omitempty"`
+}
+
+// ImportResult итог импорта. Error заполняется, если входные данные
+// не удалось дочитать или сохранение пакета прервалось: записи до этого
+// места уже обработаны
+type ImportResult struct {
+	Created int                `json:"created"`
+	Failed  int                `json:"failed"`
+	Items   []ImportItemResult `json:"items"`
+	Error   string             `json:"error,omitempty"`
+}
+
+func (res *ImportResult) add(item ImportItemResult) {
+	if item.Status == domain.BulkItemCreated {
+		res.Created++
+	} else {
+		res.Failed++
+	}
+	res.Items = append(res.Items, item)
+}
+
+func (res *ImportResult) fail(index, code int, message string) {
+	res.add(ImportItemResult{Index: index, Status: domain.BulkItemFailed, ErrorCode: code, Error: message})
+}
+
+// ImportUsers принимает JSON массив или NDJSON (Content-Type application/x-ndjson)
+// и сохраняет записи пакетами по BatchSize
+func (h *BulkHandler) ImportUsers(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.user.ImportUsers"
+	log := logger.FromContext(r.Context()).With(slog.String("op", op))
+
+	// загрузка и ответ с результатами могут длиться дольше общего таймаута
+	// сервера. Без ответа клиент повторит уже сохраненный импорт.
+	// Чтение все же ограничено ReadTimeout, чтобы медленный клиент
+	// не занимал соединение бесконечно
+	rc := http.NewResponseController(w)
+	_ = rc.SetReadDeadline(time.Now().Add(h.cfg.ReadTimeout))
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	body := http.MaxBytesReader(w, r.Body, h.cfg.MaxBodyBytes)
+
+	var next func() (json.RawMessage, error)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == contentTypeNDJSON {
+		next = ndjsonItems(body)
+	} else {
+		next = jsonArrayItems(body)
+	}
+
+	var (
+		result ImportResult
+		batch  []domain.User
+		// batchIndexes номера записей пакета во входных данных
+		batchIndexes []int
+		index        int
+		appErr       *domain.AppError
+	)
+
+	flush := func() *domain.AppError {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		items, appErr := h.bulkUseCase.ImportUsers(r.Context(), batch)
+		if appErr != nil {
+			return appErr
+		}
+
+		for _, item := range items {
+			res := ImportItemResult{Index: batchIndexes[item.Index], Status: item.Status, ID: item.User.ID}
+			if item.Err != nil {
+				res.ErrorCode = response.DomainErrorCode(item.Err)
+				res.Error = response.DomainErrorMessage(item.Err)
+			}
+			result.add(res)
+		}
+
+		batch = batch[:0]
+		batchIndexes = batchIndexes[:0]
+		return nil
+	}
+
+	for ; ; index++ {
+		raw, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			log.Warn("failed to read import body", slog.Int("index", index), sl.Err(err))
+
+			if index == 0 {
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					response.SendError(w, r, http.StatusRequestEntityTooLarge, "request body is too large", "")
+					return
+				}
+				response.SendBadRequest(w, r, "bad request")
+				return
+			}
+
+			result.Error = fmt.Sprintf("input truncated at item %d: %s", index, err)
+			break
+		}
+
+		if index >= h.cfg.MaxItems {
+			result.fail(index, response.ErrCodeInvalidInput, fmt.Sprintf("bulk import is limited to %d items", h.cfg.MaxItems))
+			continue
+		}
+
+		var item ImportItem
+		if err := json.Unmarshal(raw, &item); err != nil {
+			result.fail(index, response.ErrCodeInvalidInput, "invalid item: "+err.Error())
+			continue
+		}
+
+		batch = append(batch, domain.User{Name: item.Name, Age: item.Age})
+		batchIndexes = append(batchIndexes, index)
+
+		if len(batch) >= h.cfg.BatchSize {
+			if appErr = flush(); appErr != nil {
+				break
+			}
+		}
+	}
+
+	if appErr == nil {
+		appErr = flush()
+	}
+	if appErr != nil {
+		if result.Created == 0 {
+			response.SendDomainError(w, r, appErr)
+			return
+		}
+
+		// предыдущие пакеты уже сохранены: клиент должен узнать, какие
+		// записи созданы, чтобы не повторять их
+		log.Error("users import interrupted", slog.Int("created", result.Created), slog.String("error", appErr.Message))
+		for _, i := range batchIndexes {
+			result.fail(i, response.DomainErrorCode(appErr), response.DomainErrorMessage(appErr))
+		}
+		result.Error = fmt.Sprintf("import stopped at item %d: %s", batchIndexes[0], response.DomainErrorMessage(appErr))
+	}
+
+	// пакеты и отклоненные записи добавляются в разном порядке
+	slices.SortFunc(result.Items, func(a, b ImportItemResult) int { return a.Index - b.Index })
+
+	log.Info("users import finished", slog.Int("created", result.Created), slog.Int("failed", result.Failed))
+	response.SendOK(w, r, "Users imported", result)
+}
+
+// jsonArrayItems читает элементы JSON массива по одному
+func jsonArrayItems(body io.Reader) func() (json.RawMessage, error) {
+	dec := json.NewDecoder(body)
+	started := false
+
+	return func() (json.RawMessage, error) {
+		if !started {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+				return nil, errors.New("expected JSON array")
+			}
+			started = true
+		}
+
+		if !dec.More() {
+			if _, err := dec.Token(); err != nil {
+				return nil, err
+			}
+			return nil, io.EOF
+		}
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		return raw, nil
+	}
+}
+
+// ndjsonItems читает по одной записи из каждой непустой строки.
+// Некорректная строка не прерывает чтение остальных
+func ndjsonItems(body io.Reader) func() (json.RawMessage, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), maxNDJSONLine)
+
+	return func() (json.RawMessage, error) {
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			return json.RawMessage(bytes.Clone(line)), nil
+		}
+
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+}
+
+// UsersPage страница списка пользователей.
+// NextOffset отсутствует на последней странице
+type UsersPage struct {
+	Users      []domain.User `json:"users"`
+	NextOffset *int          `json:"next_offset,omitempty"`
+}
+
+// ListUsers возвращает пользователей по фильтрам, упорядоченных по ID
+func (h *BulkHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseUserFilter(r)
+	if err != nil {
+		response.SendBadRequest(w, r, err.Error())
+		return
+	}
+
+	limit, offset, err := request.ParsePage(r)
+	if err != nil {
+		response.SendBadRequest(w, r, err.Error())
+		return
+	}
+
+	users, appErr := h.bulkUseCase.ListUsers(r.Context(), filter, limit, offset)
+	if appErr != nil {
+		response.SendDomainError(w, r, appErr)
+		return
+	}
+
+	page := UsersPage{Users: users}
+	if len(users) == limit {
+		next := offset + limit
+		page.NextOffset = &next
+	}
+
+	response.SendOK(w, r, "Users", page)
+}
+
+// ExportUsers выгружает пользователей по тем же фильтрам, что и ListUsers.
+// Формат задается параметром format (ndjson, csv) или заголовком Accept
+func (h *BulkHandler) ExportUsers(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.user.ExportUsers"
+	log := logger.FromContext(r.Context()).With(slog.String("op", op))
+
+	filter, err := parseUserFilter(r)
+	if err != nil {
+		response.SendBadRequest(w, r, err.Error())
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" && strings.Contains(r.Header.Get("Accept"), contentTypeCSV) {
+		format = "csv"
+	}
+
+	var enc exportEncoder
+	switch format {
+	case "", "ndjson":
+		enc = &ndjsonEncoder{enc: json.NewEncoder(w)}
+	case "csv":
+		enc = &csvEncoder{w: csv.NewWriter(w)}
+	default:
+		response.SendBadRequest(w, r, "invalid format")
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	// выгрузка может длиться дольше общего таймаута сервера
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	started := false
+	start := func() error {
+		started = true
+		w.Header().Set("Content-Type", enc.contentType())
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="users.%s"`, enc.extension()))
+		w.WriteHeader(http.StatusOK)
+		return enc.header()
+	}
+
+	rows := 0
+	appErr := h.bulkUseCase.ExportUsers(r.Context(), filter, func(user domain.User) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+
+		if err := enc.user(user); err != nil {
+			return err
+		}
+
+		rows++
+		if h.cfg.FlushEvery > 0 && rows%h.cfg.FlushEvery == 0 {
+			if err := enc.flush(); err != nil {
+				return err
+			}
+			_ = rc.Flush()
+		}
+		return nil
+	})
+
+	if appErr != nil {
+		if !started {
+			response.SendDomainError(w, r, appErr)
+			return
+		}
+		// статус уже отправлен: клиент увидит оборванный поток
+		log.Error("users export interrupted", slog.Int("rows", rows), slog.String("error", appErr.Message))
+		panic(http.ErrAbortHandler)
+	}
+
+	if !started {
+		if err := start(); err != nil {
+			log.Error("failed to write export header", sl.Err(err))
+			return
+		}
+	}
+
+	if err := enc.flush(); err != nil {
+		log.Error("failed to flush export", sl.Err(err))
+		return
+	}
+
+	log.Info("users export finished", slog.Int("rows", rows))
+}
+
+type exportEncoder interface {
+	contentType() string
+	extension() string
+	header() error
+	user(domain.User) error
+	flush() error
+}
+
+type ndjsonEncoder struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonEncoder) contentType() string         { return contentTypeNDJSON }
+func (e *ndjsonEncoder) extension() string           { return "ndjson" }
+func (e *ndjsonEncoder) header() error               { return nil }
+func (e *ndjsonEncoder) user(user domain.User) error { return e.enc.Encode(user) }
+func (e *ndjsonEncoder) flush() error                { return nil }
+
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func (e *csvEncoder) contentType() string { return contentTypeCSV + "; charset=utf-8" }
+func (e *csvEncoder) extension() string   { return "csv" }
+
+func (e *csvEncoder) header() error {
+	return e.w.Write([]string{"id", "name", "age", "created_date"})
+}
+
+func (e *csvEncoder) user(user domain.User) error {
+	return e.w.Write([]string{
+		strconv.FormatUint(uint64(user.ID), 10),
+		csvCell(user.Name),
+		strconv.Itoa(user.Age),
+		user.CreatedDate.Format(time.RFC3339Nano),
+	})
+}
+
+func (e *csvEncoder) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// csvCell экранирует значение, которое табличный редактор принял бы
+// за формулу (CSV injection): перед ним ставится апостроф
+func csvCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// parseUserFilter читает фильтры name, min_age, max_age,
+// created_after и created_before (RFC 3339)
+func parseUserFilter(r *http.Request) (domain.UserFilter, error) {
+	q := r.URL.Query()
+	filter := domain.UserFilter{Name: q.Get("name")}
+
+	for param, dst := range map[string]**int{"min_age": &filter.MinAge, "max_age": &filter.MaxAge} {
+		if v := q.Get(param); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s", param)
+			}
+			*dst = &n
+		}
+	}
+
+	for param, dst := range map[string]**time.Time{"created_after": &filter.CreatedAfter, "created_before": &filter.CreatedBefore} {
+		if v := q.Get(param); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s", param)
+			}
+			*dst = &t
+		}
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,189 @@
+package webhook
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/api/request"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/api/response"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/logger"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/logger/sl"
+	"github.com/go-chi/render"
+)
+
+type Handler struct {
+	webhookUseCase domain.WebhookUseCase
+}
+
+func NewWebhookHandler(webhookUseCase domain.WebhookUseCase) *Handler {
+	return &Handler{webhookUseCase: webhookUseCase}
+}
+
+// SubscriptionRequest тело запроса на создание и изменение подписки
+type SubscriptionRequest struct {
+	URL    string                `json:"url"`
+	Events []domain.WebhookEvent `json:"events"`
+	Secret string                `json:"secret,omitempty"`
+	Active *bool                 `json:"active,omitempty"`
+}
+
+func (req SubscriptionRequest) toDomain() domain.WebhookSubscription {
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	return domain.WebhookSubscription{
+		URL:    req.URL,
+		Events: req.Events,
+		Secret: req.Secret,
+		Active: active,
+	}
+}
+
+// CreatedSubscription ответ на создание подписки.
+// Секрет возвращается только один раз
+type CreatedSubscription struct {
+	domain.WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+func (h *Handler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.webhook.CreateSubscription"
+	log := logger.FromContext(r.Context()).With(slog.String("op", op))
+
+	var req SubscriptionRequest
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		log.Error("failed to decode request body", sl.Err(err))
+		response.SendBadRequest(w, r, "bad request")
+		return
+	}
+
+	sub, err := h.webhookUseCase.CreateSubscription(r.Context(), req.toDomain())
+	if err != nil {
+		response.SendDomainError(w, r, err)
+		return
+	}
+
+	response.SendCreated(w, r, "Webhook subscription created", CreatedSubscription{
+		WebhookSubscription: sub,
+		Secret:              sub.Secret,
+	})
+}
+
+func (h *Handler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.webhookUseCase.ListSubscriptions(r.Context())
+	if err != nil {
+		response.SendDomainError(w, r, err)
+		return
+	}
+
+	response.SendOK(w, r, "Webhook subscriptions", subs)
+}
+
+func (h *Handler) GetSubscriptionByID(w http.ResponseWriter, r *http.Request) {
+	id, err := request.ParseID(r, "id")
+	if err != nil {
+		response.SendBadRequest(w, r, err.Error())
+		return
+	}
+
+	sub, appErr := h.webhookUseCase.GetSubscriptionById(r.Context(), id)
+	if appErr != nil {
+		response.SendDomainError(w, r, appErr)
+		return
+	}
+
+	response.SendOK(w, r, "Webhook subscription", sub)
+}
+
+func (h *Handler) UpdateSubscription(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.webhook.UpdateSubscription"
+	log := logger.FromContext(r.Context()).With(slog.String("op", op))
+
+	id, err := request.ParseID(r, "id")
+	if err != nil {
+		response.SendBadRequest(w, r, err.Error())
+		return
+	}
+
+	var req SubscriptionRequest
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		log.Error("failed to decode request body", sl.Err(err))
+		response.SendBadRequest(w, r, "bad request")
+		return
+	}
+
+	sub := req.toDomain()
+	sub.ID = id
+
+	updated, appErr := h.webhookUseCase.UpdateSubscription(r.Context(), sub)
+	if appErr != nil {
+		response.SendDomainError(w, r, appErr)
+		return
+	}
+
+	response.SendOK(w, r, "Webhook subscription updated", updated)
+}
+
+func (h *Handler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	id, err := request.ParseID(r, "id")
+	if err != nil {
+		response.SendBadRequest(w, r, err.Error())
+		return
+	}
+
+	if appErr := h.webhookUseCase.DeleteSubscriptionById(r.Context(), id); appErr != nil {
+		response.SendDomainError(w, r, appErr)
+		return
+	}
+
+	response.SendNoContent(w, r)
+}
+
+// ListDeliveries возвращает историю доставок подписки.
+// Параметры limit и offset задают страницу
+func (h *Handler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, err := request.ParseID(r, "id")
+	if err != nil {
+		response.SendBadRequest(w, r, err.Error())
+		return
+	}
+
+	limit, offset, err := request.ParsePage(r)
+	if err != nil {
+		response.SendBadRequest(w, r, err.Error())
+		return
+	}
+
+	deliveries, appErr := h.webhookUseCase.ListDeliveries(r.Context(), id, limit, offset)
+	if appErr != nil {
+		response.SendDomainError(w, r, appErr)
+		return
+	}
+
+	response.SendOK(w, r, "Webhook deliveries", deliveries)
+}
+
+func (h *Handler) RetryDelivery(w http.ResponseWriter, r *http.Request) {
+	id, err := request.ParseID(r, "id")
+	if err != nil {
+		response.SendBadRequest(w, r, err.Error())
+		return
+	}
+
+	deliveryID, err := request.ParseID(r, "deliveryID")
+	if err != nil {
+		response.SendBadRequest(w, r, err.Error())
+		return
+	}
+
+	delivery, appErr := h.webhookUseCase.RetryDelivery(r.Context(), id, deliveryID)
+	if appErr != nil {
+		response.SendDomainError(w, r, appErr)
+		return
+	}
+
+	response.SendSuccess(w, r, http.StatusAccepted, "Webhook delivery scheduled", delivery)
+}
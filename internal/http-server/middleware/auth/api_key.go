@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/config"
+)
+
+const HeaderAPIKey = "X-API-Key"
+
+type apiKeyAuthenticator struct {
+	repo domain.APIKeyRepository
+}
+
+// NewAPIKeyAuthenticator проверяет заголовок X-API-Key по хэшам из repo
+func NewAPIKeyAuthenticator(repo domain.APIKeyRepository) *apiKeyAuthenticator {
+	return &apiKeyAuthenticator{repo: repo}
+}
+
+func (a *apiKeyAuthenticator) Method() string {
+	return domain.AuthMethodAPIKey
+}
+
+func (a *apiKeyAuthenticator) Authenticate(r *http.Request) (domain.Principal, error) {
+	raw := r.Header.Get(HeaderAPIKey)
+	if raw == "" {
+		return domain.Principal{}, ErrNoCredentials
+	}
+
+	key, err := a.repo.GetAPIKeyByHash(HashAPIKey(raw))
+	if err != nil {
+		if err.Code == http.StatusNotFound {
+			return domain.Principal{}, ErrInvalidCredentials
+		}
+		return domain.Principal{}, fmt.Errorf("%w: failed to look up API key: %s", ErrUnavailable, err.Message)
+	}
+
+	if key.RevokedAt != nil {
+		return domain.Principal{}, fmt.Errorf("%w: API key %q is revoked", ErrInvalidCredentials, key.Name)
+	}
+
+	return domain.Principal{
+		ID:     "api_key:" + key.Name,
+		Name:   key.Name,
+		Method: domain.AuthMethodAPIKey,
+		Roles:  key.Roles,
+		UserID: key.UserID,
+	}, nil
+}
+
+// HashAPIKey возвращает hex SHA-256 ключа в том виде, в котором он хранится
+func HashAPIKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// staticKeyRepository API ключи из конфигурации
+type staticKeyRepository struct {
+	keys map[string]domain.APIKey
+}
+
+func NewStaticKeyRepository(keys []config.APIKey) *staticKeyRepository {
+	repo := &staticKeyRepository{keys: make(map[string]domain.APIKey, len(keys))}
+
+	for i, k := range keys {
+		hash := strings.ToLower(k.Hash)
+		repo.keys[hash] = domain.APIKey{
+			ID:     uint(i + 1),
+			Name:   k.Name,
+			Hash:   hash,
+			Roles:  k.Roles,
+			UserID: k.UserID,
+		}
+	}
+
+	return repo
+}
+
+func (r *staticKeyRepository) GetAPIKeyByHash(hash string) (domain.APIKey, *domain.AppError) {
+	key, ok := r.keys[hash]
+	if !ok {
+		return domain.APIKey{}, domain.NewNotFoundError("API key not found")
+	}
+	return key, nil
+}
@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/api/response"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/logger/sl"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/metrics"
+	"github.com/getsentry/sentry-go"
+)
+
+var (
+	// ErrNoCredentials запрос не содержит данных для этого способа аутентификации
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrInvalidCredentials данные переданы, но не прошли проверку
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrUnavailable данные нельзя проверить, например недоступна БД ключей
+	ErrUnavailable = errors.New("credentials store unavailable")
+)
+
+// Authenticator проверяет один способ аутентификации
+type Authenticator interface {
+	Method() string
+	Authenticate(r *http.Request) (domain.Principal, error)
+}
+
+// New возвращает middleware, который пробует аутентификаторы по порядку
+// и сохраняет Principal в контексте. Запросы без учетных данных проходят
+// анонимно, чтобы их можно было отклонить позже через Required.
+// Неверные учетные данные сразу отклоняются с кодом 401, а недоступность
+// хранилища учетных данных — с кодом 503, чтобы не выглядеть как неверный ключ
+func New(log *slog.Logger, authenticators ...Authenticator) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(slog.String("component", "middleware/auth"))
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			for _, a := range authenticators {
+				principal, err := a.Authenticate(r)
+				if errors.Is(err, ErrNoCredentials) {
+					continue
+				}
+
+				if errors.Is(err, ErrUnavailable) {
+					metrics.AuthAttempts.WithLabelValues(a.Method(), "error").Inc()
+					log.ErrorContext(r.Context(), "authentication unavailable",
+						slog.String("method", a.Method()),
+						sl.Err(err),
+					)
+					response.SendError(w, r, http.StatusServiceUnavailable, "Authentication is temporarily unavailable", err.Error())
+					return
+				}
+
+				if err != nil {
+					metrics.AuthAttempts.WithLabelValues(a.Method(), "failure").Inc()
+					log.WarnContext(r.Context(), "authentication failed",
+						slog.String("method", a.Method()),
+						sl.Err(err),
+					)
+					sendUnauthorized(w, r)
+					return
+				}
+
+				metrics.AuthAttempts.WithLabelValues(a.Method(), "success").Inc()
+				// principal попадает в контекст ниже, поэтому пишется явно
+				log.DebugContext(r.Context(), "request authenticated", slog.Any("principal", principal))
+
+				if hub := sentry.GetHubFromContext(r.Context()); hub != nil {
+					hub.Scope().SetUser(sentry.User{ID: principal.ID, Username: principal.Name})
+					hub.Scope().SetTag("auth_method", principal.Method)
+				}
+
+				next.ServeHTTP(w, r.WithContext(domain.ContextWithPrincipal(r.Context(), principal)))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// Required отклоняет анонимные запросы
+func Required(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := domain.PrincipalFromContext(r.Context()); !ok {
+			metrics.AuthAttempts.WithLabelValues("none", "missing").Inc()
+			sendUnauthorized(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(fn)
+}
+
+// RequireRole пропускает только вызывающих с одной из ролей
+func RequireRole(roles ...domain.Role) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			principal, _ := domain.PrincipalFromContext(r.Context())
+			for _, role := range roles {
+				if principal.HasRole(role) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			response.SendCommonError(w, r, response.ErrCodeForbidden)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+func sendUnauthorized(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+	response.SendUnauthorized(w, r, "Unauthorized")
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// claims поля токена, из которых строится Principal
+type claims struct {
+	jwt.RegisteredClaims
+	Name   string   `json:"name,omitempty"`
+	Roles  []string `json:"roles,omitempty"`
+	UserID uint     `json:"user_id,omitempty"`
+}
+
+type jwtAuthenticator struct {
+	secret []byte
+	keys   map[string]any
+	parser *jwt.Parser
+}
+
+// NewJWTAuthenticator проверяет bearer токены. Токены с заголовком kid
+// проверяются ключом из JWKS файла, остальные - HMAC секретом
+func NewJWTAuthenticator(cfg config.JWT) (*jwtAuthenticator, error) {
+	a := &jwtAuthenticator{secret: []byte(cfg.Secret)}
+
+	var methods []string
+	if cfg.Secret != "" {
+		methods = append(methods, "HS256", "HS384", "HS512")
+	}
+
+	if cfg.JWKSFile != "" {
+		keys, err := LoadJWKS(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		a.keys = keys
+		methods = append(methods, "RS256", "RS384", "RS512", "ES256", "ES384", "ES512")
+	}
+
+	if len(methods) == 0 {
+		return nil, errors.New("jwt: either secret or jwks_file must be configured")
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(cfg.Leeway),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+
+	a.parser = jwt.NewParser(opts...)
+
+	return a, nil
+}
+
+func (a *jwtAuthenticator) Method() string {
+	return domain.AuthMethodJWT
+}
+
+func (a *jwtAuthenticator) Authenticate(r *http.Request) (domain.Principal, error) {
+	header := r.Header.Get("Authorization")
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return domain.Principal{}, ErrNoCredentials
+	}
+
+	var c claims
+	if _, err := a.parser.ParseWithClaims(strings.TrimSpace(token), &c, a.keyFunc); err != nil {
+		return domain.Principal{}, fmt.Errorf("%w: %w", ErrInvalidCredentials, err)
+	}
+
+	if c.Subject == "" {
+		return domain.Principal{}, fmt.Errorf("%w: token has no subject", ErrInvalidCredentials)
+	}
+
+	return domain.Principal{
+		ID:     c.Subject,
+		Name:   c.Name,
+		Method: domain.AuthMethodJWT,
+		Roles:  c.Roles,
+		UserID: c.UserID,
+	}, nil
+}
+
+func (a *jwtAuthenticator) keyFunc(token *jwt.Token) (any, error) {
+	if kid, ok := token.Header["kid"].(string); ok && kid != "" {
+		key, ok := a.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		return key, nil
+	}
+
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok && len(a.secret) > 0 {
+		return a.secret, nil
+	}
+
+	return nil, fmt.Errorf("no key for signing method %s", token.Method.Alg())
+}
@@ -0,0 +1,50 @@
+package httpmetrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/api/route"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/metrics"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// New возвращает middleware, который считает запросы и их длительность
+// по шаблону маршрута chi. Длительность записывается с exemplar trace_id,
+// поэтому middleware должен стоять после middleware, начинающих трассировку,
+// и перед recoverer, чтобы паника учитывалась как ответ 500
+func New() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			start := time.Now()
+
+			defer func() {
+				// паника, которую recoverer пробросил дальше (http.ErrAbortHandler),
+				// учитывается как ошибка сервера
+				rec := recover()
+
+				status := ww.Status()
+				switch {
+				case rec != nil:
+					status = http.StatusInternalServerError
+				case status == 0:
+					status = http.StatusOK
+				}
+				labels := []string{r.Method, route.Pattern(r), strconv.Itoa(status)}
+
+				metrics.HTTPRequests.WithLabelValues(labels...).Inc()
+				metrics.ObserveWithTrace(r.Context(), metrics.HTTPRequestDuration.WithLabelValues(labels...), time.Since(start).Seconds())
+
+				if rec != nil {
+					panic(rec)
+				}
+			}()
+
+			next.ServeHTTP(ww, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
@@ -0,0 +1,214 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/config"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/api/response"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/logger"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/logger/sl"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const (
+	HeaderKey      = "Idempotency-Key"
+	HeaderReplayed = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+)
+
+// replayedHeaders заголовки ответа, которые сохраняются вместе с телом
+var replayedHeaders = []string{"Content-Type", "Location"}
+
+// New возвращает middleware, обрабатывающий заголовок Idempotency-Key.
+// Первый запрос с ключом выполняется и его ответ сохраняется,
+// повторные запросы с тем же ключом и телом получают сохраненный ответ.
+// Повтор ключа с другим телом отклоняется с кодом 422.
+// Ключи разных вызывающих не пересекаются
+func New(log *slog.Logger, repo domain.IdempotencyRepository, cfg config.Idempotency) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(slog.String("component", "middleware/idempotency"))
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(HeaderKey)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			log := logger.Bind(r.Context(), log).With(slog.String("idempotency_key", key))
+
+			if len(key) > maxKeyLength {
+				response.SendBadRequest(w, r, "Idempotency-Key is too long")
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, cfg.MaxBodyBytes+1))
+			if err != nil {
+				log.Error("failed to read request body", sl.Err(err))
+				response.SendBadRequest(w, r, "bad request")
+				return
+			}
+			if int64(len(body)) > cfg.MaxBodyBytes {
+				response.SendError(w, r, http.StatusRequestEntityTooLarge, "request body is too large", "")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			// Пока запрос выполняется, ключ занят не дольше LockTimeout,
+			// после сохранения ответа запись хранится TTL
+			now := time.Now()
+			record := domain.IdempotencyRecord{
+				Key:         scopedKey(r, key),
+				Fingerprint: fingerprint(r, body),
+				Token:       newToken(),
+				CreatedAt:   now,
+				ExpiresAt:   now.Add(cfg.LockTimeout),
+			}
+
+			existing, reserved, appErr := repo.Reserve(r.Context(), record)
+			if appErr != nil {
+				log.Error("failed to reserve idempotency key", slog.String("error", appErr.Message))
+				response.SendCommonError(w, r, response.ErrCodeDatabaseError)
+				return
+			}
+
+			if !reserved {
+				switch {
+				case existing.Fingerprint != record.Fingerprint:
+					log.Warn("idempotency key reused with a different request")
+					response.SendCommonError(w, r, response.ErrCodeIdempotencyMismatch)
+				case !existing.Completed():
+					response.SendCommonError(w, r, response.ErrCodeRequestInProgress)
+				default:
+					log.Info("replaying stored response")
+					replay(w, existing)
+				}
+				return
+			}
+
+			// Ответ сохраняется, даже если клиент уже отключился
+			ctx := context.WithoutCancel(r.Context())
+
+			var buf bytes.Buffer
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(&buf)
+
+			// При панике освобождаем ключ, чтобы клиент мог повторить запрос
+			defer func() {
+				if rec := recover(); rec != nil {
+					release(ctx, log, repo, record)
+					panic(rec)
+				}
+			}()
+
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			// Ответы 5xx не сохраняются: такой запрос имеет смысл повторить
+			if status >= http.StatusInternalServerError {
+				release(ctx, log, repo, record)
+				return
+			}
+
+			record.StatusCode = status
+			record.Header = http.Header{}
+			for _, name := range replayedHeaders {
+				if v := ww.Header().Values(name); len(v) > 0 {
+					record.Header[name] = v
+				}
+			}
+			record.Body = buf.Bytes()
+			record.ExpiresAt = record.CreatedAt.Add(cfg.TTL)
+
+			switch appErr := repo.Complete(ctx, record); {
+			case appErr == nil:
+			case appErr.Code == http.StatusNotFound:
+				// запрос выполнялся дольше LockTimeout, и ключ мог занять другой запрос
+				log.Warn("idempotency key reservation expired before the response was stored")
+			default:
+				log.Error("failed to store idempotent response", slog.String("error", appErr.Message))
+			}
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// Cleanup периодически удаляет истекшие ключи до отмены контекста
+func Cleanup(ctx context.Context, log *slog.Logger, repo domain.IdempotencyRepository, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			n, err := repo.DeleteExpired(ctx, now)
+			if err != nil {
+				log.Error("failed to delete expired idempotency keys", slog.String("error", err.Message))
+				continue
+			}
+			if n > 0 {
+				log.Debug("expired idempotency keys deleted", slog.Int64("count", n))
+			}
+		}
+	}
+}
+
+func replay(w http.ResponseWriter, record domain.IdempotencyRecord) {
+	for name, values := range record.Header {
+		for _, v := range values {
+			w.Header().Add(name, v)
+		}
+	}
+	w.Header().Set(HeaderReplayed, "true")
+	w.WriteHeader(record.StatusCode)
+	_, _ = w.Write(record.Body)
+}
+
+func release(ctx context.Context, log *slog.Logger, repo domain.IdempotencyRepository, record domain.IdempotencyRecord) {
+	if err := repo.Delete(ctx, record.Key, record.Token); err != nil {
+		log.Error("failed to release idempotency key", slog.String("error", err.Message))
+	}
+}
+
+// newToken случайный токен резерва ключа
+func newToken() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// scopedKey привязывает ключ к вызывающему, методу и пути, чтобы один
+// ключ на разных эндпоинтах или у разных клиентов не конфликтовал
+func scopedKey(r *http.Request, key string) string {
+	principal, _ := domain.PrincipalFromContext(r.Context())
+	return strings.Join([]string{principal.ID, r.Method, r.URL.Path, key}, " ")
+}
+
+// fingerprint отпечаток запроса: метод, путь и тело
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method))
+	h.Write([]byte{0})
+	h.Write([]byte(r.URL.Path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
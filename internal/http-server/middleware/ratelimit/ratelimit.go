@@ -0,0 +1,243 @@
+package ratelimit
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/config"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/api/response"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/api/route"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/logger/sl"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/metrics"
+)
+
+// Способы определения клиента
+const (
+	KeyByIP     = "ip"
+	KeyByAPIKey = "api_key"
+	KeyByUser   = "user"
+)
+
+const anyMethod = "*"
+
+// KeyFunc извлекает идентификатор клиента из запроса.
+// false означает, что запрос нельзя идентифицировать этим способом
+type KeyFunc func(r *http.Request) (string, bool)
+
+type Option func(*limiter)
+
+// WithKeyFunc регистрирует способ идентификации клиента под именем name,
+// которое можно указать в key_by
+func WithKeyFunc(name string, fn KeyFunc) Option {
+	return func(l *limiter) {
+		l.keyFuncs[name] = fn
+	}
+}
+
+type rule struct {
+	keyBy  string
+	policy Policy
+	header string
+}
+
+type limiter struct {
+	log      *slog.Logger
+	store    Store
+	def      rule
+	defScope string
+	routes   map[string]rule
+	keyFuncs map[string]KeyFunc
+	now      func() time.Time
+}
+
+// New возвращает middleware, ограничивающий частоту запросов алгоритмом
+// token bucket. Политики задаются для маршрутов chi, остальные запросы
+// используют политику по умолчанию. Middleware должен стоять после
+// middleware.RealIP, чтобы по умолчанию клиенты различались по настоящему IP,
+// и после auth.New, если клиенты различаются по пользователю или ключу.
+// Политика с неположительными Requests или Period считается ошибкой
+func New(log *slog.Logger, store Store, cfg config.RateLimit, opts ...Option) (func(next http.Handler) http.Handler, error) {
+	if !cfg.Enabled {
+		return func(next http.Handler) http.Handler { return next }, nil
+	}
+
+	def, err := newRule(cfg.KeyBy, cfg.Default)
+	if err != nil {
+		return nil, fmt.Errorf("default policy: %w", err)
+	}
+
+	l := &limiter{
+		log:      log.With(slog.String("component", "middleware/ratelimit")),
+		store:    store,
+		def:      def,
+		defScope: "default",
+		keyFuncs: map[string]KeyFunc{
+			KeyByIP:     ByIP,
+			KeyByAPIKey: ByAPIKey,
+			KeyByUser:   ByUser,
+		},
+		routes: make(map[string]rule, len(cfg.Routes)),
+		now:    time.Now,
+	}
+
+	for _, rc := range cfg.Routes {
+		keyBy := rc.KeyBy
+		if keyBy == "" {
+			keyBy = cfg.KeyBy
+		}
+		rl, err := newRule(keyBy, rc.Policy)
+		if err != nil {
+			return nil, fmt.Errorf("policy for %s: %w", routeKey(rc.Method, rc.Pattern), err)
+		}
+		l.routes[routeKey(rc.Method, rc.Pattern)] = rl
+	}
+
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			pattern := route.Pattern(r)
+			rl, bucketScope := l.ruleFor(r.Method, pattern)
+
+			keyType, client := l.identify(r, rl.keyBy)
+			key := strings.Join([]string{keyType, client, bucketScope}, "|")
+
+			res, err := l.store.Take(r.Context(), key, rl.policy, l.now())
+			if err != nil {
+				// Недоступность хранилища не должна ронять API
+				l.log.ErrorContext(r.Context(), "rate limit store failed, request allowed", sl.Err(err))
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			setHeaders(w, rl, res)
+
+			if !res.Allowed {
+				metrics.RateLimitRejections.WithLabelValues(pattern, keyType).Inc()
+				w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+				response.SendError(w, r, http.StatusTooManyRequests, "Too many requests", "")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}, nil
+}
+
+// PreAuth возвращает middleware с единственной политикой cfg.PreAuth по IP.
+// Он ставится перед auth.New: иначе запросы с неверным ключом или токеном
+// отклоняются с 401 раньше ограничения, и подбор учетных данных не
+// ограничен. Бакеты PreAuth не пересекаются с бакетами New
+func PreAuth(log *slog.Logger, store Store, cfg config.RateLimit) (func(next http.Handler) http.Handler, error) {
+	return New(log, store, config.RateLimit{Enabled: cfg.Enabled, KeyBy: KeyByIP, Default: cfg.PreAuth},
+		func(l *limiter) { l.defScope = "pre_auth" })
+}
+
+// ruleFor возвращает политику для маршрута и область бакета:
+// у каждой политики маршрута свой бакет, политика по умолчанию общая
+func (l *limiter) ruleFor(method, pattern string) (rule, string) {
+	if rl, ok := l.routes[routeKey(method, pattern)]; ok {
+		return rl, routeKey(method, pattern)
+	}
+	if rl, ok := l.routes[routeKey(anyMethod, pattern)]; ok {
+		return rl, routeKey(anyMethod, pattern)
+	}
+	return l.def, l.defScope
+}
+
+// identify определяет клиента, при неудаче используется IP
+func (l *limiter) identify(r *http.Request, keyBy string) (string, string) {
+	if fn, ok := l.keyFuncs[keyBy]; ok {
+		if key, ok := fn(r); ok {
+			return keyBy, key
+		}
+	}
+
+	key, _ := ByIP(r)
+	return KeyByIP, key
+}
+
+// ByIP идентифицирует клиента по адресу, выставленному middleware.RealIP
+func ByIP(r *http.Request) (string, bool) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr, r.RemoteAddr != ""
+	}
+	return host, true
+}
+
+// ByAPIKey идентифицирует клиента по проверенному API ключу. Сырой
+// заголовок X-API-Key не используется: перебирая произвольные значения,
+// клиент получал бы новый бакет на каждый запрос
+func ByAPIKey(r *http.Request) (string, bool) {
+	principal, ok := domain.PrincipalFromContext(r.Context())
+	if !ok || principal.Method != domain.AuthMethodAPIKey {
+		return "", false
+	}
+	return principal.ID, true
+}
+
+// ByUser идентифицирует клиента по Principal, поэтому middleware
+// должен стоять после аутентификации
+func ByUser(r *http.Request) (string, bool) {
+	principal, ok := domain.PrincipalFromContext(r.Context())
+	if !ok {
+		return "", false
+	}
+	return principal.ID, true
+}
+
+// newRule заголовки RateLimit-Limit и RateLimit-Policy описывают один
+// и тот же бакет: Burst запросов за время, за которое пустой бакет
+// наполняется заново. При Burst = Requests это Requests за Period
+func newRule(keyBy string, cfg config.RateLimitPolicy) (rule, error) {
+	if cfg.Requests <= 0 {
+		return rule{}, fmt.Errorf("requests must be positive, got %d", cfg.Requests)
+	}
+	if cfg.Period <= 0 {
+		return rule{}, fmt.Errorf("period must be positive, got %s", cfg.Period)
+	}
+
+	burst := cfg.Burst
+	if burst <= 0 {
+		burst = cfg.Requests
+	}
+
+	return rule{
+		keyBy: keyBy,
+		policy: Policy{
+			Rate:  float64(cfg.Requests) / cfg.Period.Seconds(),
+			Burst: burst,
+		},
+		header: fmt.Sprintf("%d;w=%d", burst, ceilSeconds(cfg.Period*time.Duration(burst)/time.Duration(cfg.Requests))),
+	}, nil
+}
+
+func setHeaders(w http.ResponseWriter, rl rule, res Result) {
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+	w.Header().Set("RateLimit-Policy", rl.header)
+}
+
+func routeKey(method, pattern string) string {
+	if method == "" {
+		method = anyMethod
+	}
+	return strings.ToUpper(method) + " " + pattern
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,107 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/config"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/http-server/middleware/auth"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/logger/handlers/slogdiscard"
+)
+
+// TestPreAuthLimitsFailedCredentials перебор ключей с одного адреса
+// получает 429, хотя каждая попытка отклоняется аутентификацией
+func TestPreAuthLimitsFailedCredentials(t *testing.T) {
+	log := slogdiscard.NewDiscardLogger()
+	cfg := config.RateLimit{
+		Enabled: true,
+		PreAuth: config.RateLimitPolicy{Requests: 3, Period: time.Minute, Burst: 3},
+	}
+
+	preAuth, err := PreAuth(log, NewMemoryStore(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authn := auth.New(log, auth.NewAPIKeyAuthenticator(auth.NewStaticKeyRepository(nil)))
+	handler := preAuth(authn(auth.Required(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))))
+
+	send := func(remoteAddr string, attempt int) int {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/users/1", nil)
+		r.RemoteAddr = remoteAddr
+		r.Header.Set(auth.HeaderAPIKey, "guess-"+strconv.Itoa(attempt))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	want := []int{401, 401, 401, 429, 429}
+	for i, status := range want {
+		if got := send("192.0.2.1:1234", i); got != status {
+			t.Fatalf("attempt %d: status %d, want %d", i+1, got, status)
+		}
+	}
+
+	if got := send("192.0.2.2:1234", 0); got != http.StatusUnauthorized {
+		t.Fatalf("other client: status %d, want 401", got)
+	}
+}
+
+func TestNewRejectsInvalidPolicy(t *testing.T) {
+	log := slogdiscard.NewDiscardLogger()
+
+	tests := []struct {
+		name   string
+		policy config.RateLimitPolicy
+	}{
+		{name: "zero requests", policy: config.RateLimitPolicy{Requests: 0, Period: time.Minute}},
+		{name: "zero period", policy: config.RateLimitPolicy{Requests: 10}},
+		{name: "negative period", policy: config.RateLimitPolicy{Requests: 10, Period: -time.Second}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(log, NewMemoryStore(), config.RateLimit{Enabled: true, Default: tt.policy}); err == nil {
+				t.Fatal("New() accepted an invalid policy")
+			}
+		})
+	}
+}
+
+// TestHeadersDescribeOneQuota RateLimit-Limit и RateLimit-Policy
+// сообщают одну квоту: емкость бакета и время его наполнения
+func TestHeadersDescribeOneQuota(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     config.RateLimitPolicy
+		wantLimit  string
+		wantPolicy string
+	}{
+		{name: "burst below requests", policy: config.RateLimitPolicy{Requests: 100, Period: time.Minute, Burst: 20}, wantLimit: "20", wantPolicy: "20;w=12"},
+		{name: "burst defaults to requests", policy: config.RateLimitPolicy{Requests: 10, Period: time.Minute}, wantLimit: "10", wantPolicy: "10;w=60"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter, err := New(slogdiscard.NewDiscardLogger(), NewMemoryStore(), config.RateLimit{Enabled: true, KeyBy: KeyByIP, Default: tt.policy})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			w := httptest.NewRecorder()
+			limiter(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).
+				ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if got := w.Header().Get("RateLimit-Limit"); got != tt.wantLimit {
+				t.Fatalf("RateLimit-Limit = %q, want %q", got, tt.wantLimit)
+			}
+			if got := w.Header().Get("RateLimit-Policy"); got != tt.wantPolicy {
+				t.Fatalf("RateLimit-Policy = %q, want %q", got, tt.wantPolicy)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Policy параметры токен-бакета: Rate токенов в секунду, емкость Burst
+type Policy struct {
+	Rate  float64
+	Burst int
+}
+
+// Result результат попытки взять токен
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// Store хранит состояние бакетов. Реализация в памяти годится для одной
+// реплики, для нескольких реплик нужна общая реализация (например, Redis)
+type Store interface {
+	Take(ctx context.Context, key string, policy Policy, now time.Time) (Result, error)
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	policy  Policy
+}
+
+// memoryStore хранит бакеты в памяти процесса
+type memoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// sweepInterval как часто удаляются заполненные бакеты
+const sweepInterval = time.Minute
+
+func NewMemoryStore() *memoryStore {
+	return &memoryStore{buckets: make(map[string]*bucket)}
+}
+
+func (s *memoryStore) Take(_ context.Context, key string, policy Policy, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) > sweepInterval {
+		s.sweep(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(policy.Burst), updated: now}
+		s.buckets[key] = b
+	}
+	b.policy = policy
+
+	return take(b, policy, now), nil
+}
+
+// sweep удаляет бакеты, которые успели полностью восстановиться:
+// они ничем не отличаются от новых
+func (s *memoryStore) sweep(now time.Time) {
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if refill(b, b.policy, now) >= float64(b.policy.Burst) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+func refill(b *bucket, policy Policy, now time.Time) float64 {
+	elapsed := now.Sub(b.updated).Seconds()
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	return math.Min(float64(policy.Burst), b.tokens+elapsed*policy.Rate)
+}
+
+func take(b *bucket, policy Policy, now time.Time) Result {
+	b.tokens = refill(b, policy, now)
+	b.updated = now
+
+	res := Result{Limit: policy.Burst}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = secondsToDuration((1 - b.tokens) / policy.Rate)
+	}
+
+	res.Remaining = int(math.Floor(b.tokens))
+	res.Reset = secondsToDuration((float64(policy.Burst) - b.tokens) / policy.Rate)
+
+	return res
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
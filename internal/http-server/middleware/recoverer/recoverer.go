@@ -0,0 +1,112 @@
+package recoverer
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/api/response"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/api/route"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/logger"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/metrics"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/redact"
+	"github.com/getsentry/sentry-go"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+// New возвращает middleware, который превращает панику обработчика в ответ
+// 500 в стандартном формате ошибки, пишет стек в лог и отправляет ошибку
+// в Sentry и New Relic. http.ErrAbortHandler пробрасывается дальше: им
+// обработчик намеренно прерывает ответ. nrApp может быть nil.
+// Sentry очищает событие сам, в New Relic текст паники и стек
+// отправляются после redactor.Scrub
+func New(log *slog.Logger, nrApp *newrelic.Application, redactor *redact.Redactor) func(next http.Handler) http.Handler {
+	log = log.With(slog.String("component", "middleware/recoverer"))
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				if err, ok := rec.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					panic(rec)
+				}
+
+				stack := debug.Stack()
+				pattern := route.Pattern(r)
+				requestID := middleware.GetReqID(r.Context())
+
+				metrics.Panics.WithLabelValues(pattern).Inc()
+
+				log.ErrorContext(r.Context(), "panic recovered",
+					slog.Any("panic", rec),
+					slog.String("method", r.Method),
+					slog.String("stack", string(stack)),
+					// событие с исключением отправит reportSentry
+					logger.SentryReported(),
+				)
+
+				reportSentry(r, rec, pattern, requestID)
+				reportNewRelic(r, nrApp, redactor, rec, pattern, stack)
+
+				// ответ уже начат или соединение передано другому протоколу:
+				// дописать ошибку нельзя
+				if ww.Status() != 0 || r.Header.Get("Connection") == "Upgrade" {
+					return
+				}
+
+				response.SendCommonError(ww, r, response.ErrCodeInternalServerError)
+			}()
+
+			next.ServeHTTP(ww, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// reportSentry использует hub запроса, чтобы событие получило его scope
+func reportSentry(r *http.Request, rec any, route, requestID string) {
+	hub := sentry.GetHubFromContext(r.Context())
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+	}
+
+	hub.WithScope(func(scope *sentry.Scope) {
+		scope.SetLevel(sentry.LevelError)
+		scope.SetTag("route", route)
+		if requestID != "" {
+			scope.SetTag("request_id", requestID)
+		}
+		hub.RecoverWithContext(r.Context(), rec)
+	})
+}
+
+// reportNewRelic отмечает ошибку в транзакции запроса. Если запрос
+// не инструментирован, ошибка записывается в отдельную транзакцию
+func reportNewRelic(r *http.Request, app *newrelic.Application, redactor *redact.Redactor, rec any, route string, stack []byte) {
+	txn := newrelic.FromContext(r.Context())
+	if txn == nil {
+		if app == nil {
+			return
+		}
+		txn = app.StartTransaction(r.Method + " " + route)
+		defer txn.End()
+	}
+
+	txn.NoticeError(newrelic.Error{
+		Message: redactor.Scrub(fmt.Sprint(rec)),
+		Class:   "panic",
+		Attributes: map[string]any{
+			"route": route,
+			"stack": redactor.Scrub(string(stack)),
+		},
+	})
+}
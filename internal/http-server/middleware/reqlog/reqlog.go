@@ -0,0 +1,27 @@
+package reqlog
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/logger"
+)
+
+// New возвращает middleware, который сохраняет в контексте логгер запроса
+// с методом и путем. Остальные данные запроса (request_id, трассировка,
+// вызывающий, маршрут) логгер берет из контекста при каждой записи,
+// поэтому middleware может стоять до аутентификации и маршрутизации
+func New(log *slog.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			reqLog := log.With(
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+			)
+
+			next.ServeHTTP(w, r.WithContext(logger.NewContext(r.Context(), reqLog)))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
@@ -0,0 +1,27 @@
+package requestid
+
+import (
+	"net/http"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/requestid"
+)
+
+// New возвращает middleware, который берет идентификатор запроса из
+// X-Request-ID или создает новый, если заголовка нет или он невалиден.
+// Идентификатор сохраняется в контексте и возвращается в ответе.
+// Заменяет middleware.RequestID из chi
+func New() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(requestid.Header)
+			if !requestid.Valid(id) {
+				id = requestid.New()
+			}
+
+			w.Header().Set(requestid.Header, id)
+			next.ServeHTTP(w, r.WithContext(requestid.NewContext(r.Context(), id)))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
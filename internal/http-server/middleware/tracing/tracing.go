@@ -0,0 +1,81 @@
+package tracing
+
+import (
+	"net/http"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/api/route"
+	"github.com/getsentry/sentry-go"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+// New возвращает middleware, который начинает транзакцию Sentry и New
+// Relic для каждого запроса и кладет в контекст hub запроса, span и
+// транзакцию. От них зависят exemplar метрик, теги ошибок и trace_id
+// в логах, поэтому middleware должен стоять перед httpmetrics и recoverer.
+// Транзакции называются по шаблону маршрута, известному после обработки.
+// Выключенный Sentry (sentryEnabled=false) и nil nrApp пропускаются
+func New(sentryEnabled bool, nrApp *newrelic.Application) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !sentryEnabled && nrApp == nil {
+			return next
+		}
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			var txn *newrelic.Transaction
+			if nrApp != nil {
+				txn = nrApp.StartTransaction(r.Method + " " + r.URL.Path)
+				defer txn.End()
+
+				txn.SetWebRequestHTTP(r)
+				w = txn.SetWebResponse(w)
+				ctx = newrelic.NewContext(ctx, txn)
+			}
+
+			var span *sentry.Span
+			if sentryEnabled {
+				hub := sentry.GetHubFromContext(ctx)
+				if hub == nil {
+					hub = sentry.CurrentHub().Clone()
+					ctx = sentry.SetHubOnContext(ctx, hub)
+				}
+				hub.Scope().SetRequest(r)
+
+				span = sentry.StartTransaction(ctx, r.Method+" "+r.URL.Path,
+					sentry.ContinueTrace(hub, r.Header.Get(sentry.SentryTraceHeader), r.Header.Get(sentry.SentryBaggageHeader)),
+					sentry.WithOpName("http.server"),
+					sentry.WithTransactionSource(sentry.SourceURL),
+				)
+				ctx = span.Context()
+			}
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			r = r.WithContext(ctx)
+
+			defer func() {
+				name := r.Method + " " + route.Pattern(r)
+				if txn != nil {
+					txn.SetName(name)
+				}
+				if span != nil {
+					status := ww.Status()
+					if status == 0 {
+						status = http.StatusOK
+					}
+
+					span.Name = name
+					span.Source = sentry.SourceRoute
+					span.Status = sentry.HTTPtoSpanStatus(status)
+					span.SetData("http.response.status_code", status)
+					span.Finish()
+				}
+			}()
+
+			next.ServeHTTP(ww, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
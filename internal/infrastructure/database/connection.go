@@ -44,6 +44,11 @@ func NewDatabase(config config.Database) (*Database, error) {
 	}, nil
 }
 
+// Close закрывает пул соединений
+func (d *Database) Close() error {
+	return d.DB.Close()
+}
+
 // ConnectionURLBuilder строит URL для подключения к БД
 func ConnectionURLBuilder(dbType string, config config.Database) (string, error) {
 	var url string
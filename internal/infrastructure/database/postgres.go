@@ -4,7 +4,8 @@ import (
 	"fmt"
 
 	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/config"
-	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/jmoiron/sqlx"
 )
 
@@ -14,11 +15,15 @@ func PostgreSQLConnection(config config.Database) (*sqlx.DB, error) {
 		return nil, err
 	}
 
-	// Define database connection for PostgreSQL.
-	db, err := sqlx.Connect("pgx", postgresConnURL)
+	connConfig, err := pgx.ParseConfig(postgresConnURL)
 	if err != nil {
-		return nil, fmt.Errorf("error, not connected to database, %w", err)
+		return nil, fmt.Errorf("error, invalid database config, %w", err)
 	}
+	// длительность запросов попадает в метрики с exemplar trace_id
+	connConfig.Tracer = queryTracer{}
+
+	// Define database connection for PostgreSQL.
+	db := sqlx.NewDb(stdlib.OpenDB(*connConfig), "pgx")
 
 	db.SetMaxOpenConns(config.MaxDBConnections)
 	db.SetMaxIdleConns(config.MaxDBIdleConnections)
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/metrics"
+	"github.com/jackc/pgx/v5"
+)
+
+type queryStartKey struct{}
+
+type queryStart struct {
+	operation string
+	at        time.Time
+}
+
+// queryTracer записывает длительность запросов pgx в гистограмму
+// с exemplar trace_id из контекста запроса
+type queryTracer struct{}
+
+func (queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, queryStartKey{}, queryStart{operation: operation(data.SQL), at: time.Now()})
+}
+
+func (queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	observeQuery(ctx, data.Err)
+}
+
+func (queryTracer) TraceCopyFromStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceCopyFromStartData) context.Context {
+	return context.WithValue(ctx, queryStartKey{}, queryStart{operation: "copy", at: time.Now()})
+}
+
+func (queryTracer) TraceCopyFromEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	observeQuery(ctx, data.Err)
+}
+
+func observeQuery(ctx context.Context, err error) {
+	start, ok := ctx.Value(queryStartKey{}).(queryStart)
+	if !ok {
+		return
+	}
+
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+
+	metrics.ObserveWithTrace(ctx, metrics.DBQueryDuration.WithLabelValues(start.operation, outcome), time.Since(start.at).Seconds())
+}
+
+// operation первое ключевое слово запроса в нижнем регистре. Неизвестные
+// слова объединяются в other, чтобы не раздувать число рядов
+func operation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "other"
+	}
+
+	switch op := strings.ToLower(fields[0]); op {
+	case "select", "insert", "update", "delete", "with", "begin", "commit", "rollback", "copy":
+		return op
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,35 @@
+package telemetry
+
+import (
+	"context"
+	"time"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/config"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+// NewNewRelic запускает агент New Relic. Без лицензионного ключа
+// агент не создается и возвращается nil
+func NewNewRelic(cfg config.NewRelic) (*newrelic.Application, error) {
+	if cfg.License == "" {
+		return nil, nil
+	}
+
+	return newrelic.NewApplication(
+		newrelic.ConfigAppName(cfg.AppName),
+		newrelic.ConfigLicense(cfg.License),
+		newrelic.ConfigCodeLevelMetricsEnabled(true),
+		newrelic.ConfigAppLogForwardingEnabled(true),
+	)
+}
+
+// ShutdownNewRelic отправляет накопленные данные до истечения ctx
+func ShutdownNewRelic(ctx context.Context, app *newrelic.Application) error {
+	timeout := 2 * time.Second
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+
+	app.Shutdown(timeout)
+	return nil
+}
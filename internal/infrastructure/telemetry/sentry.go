@@ -0,0 +1,120 @@
+package telemetry
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/config"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/redact"
+	"github.com/getsentry/sentry-go"
+)
+
+// NewSentry инициализирует клиент Sentry. Без DSN клиент
+// не создается и возвращается false. События и breadcrumbs очищаются
+// redactor перед отправкой
+func NewSentry(cfg config.Sentry, env string, redactor *redact.Redactor) (bool, error) {
+	if cfg.DSN == "" {
+		return false, nil
+	}
+
+	err := sentry.Init(sentry.ClientOptions{
+		Dsn:              cfg.DSN,
+		Environment:      env,
+		EnableTracing:    true,
+		TracesSampleRate: cfg.TracesSampleRate,
+		BeforeSend: func(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+			redactEvent(event, redactor)
+			return event
+		},
+		BeforeSendTransaction: func(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+			redactEvent(event, redactor)
+			return event
+		},
+		BeforeBreadcrumb: func(breadcrumb *sentry.Breadcrumb, _ *sentry.BreadcrumbHint) *sentry.Breadcrumb {
+			redactBreadcrumb(breadcrumb, redactor)
+			return breadcrumb
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// FlushSentry отправляет накопленные события до истечения ctx
+func FlushSentry(ctx context.Context) error {
+	timeout := 2 * time.Second
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+
+	if !sentry.Flush(timeout) {
+		return context.DeadlineExceeded
+	}
+	return nil
+}
+
+// redactEvent скрывает чувствительные данные во всех частях события,
+// которые могут содержать пользовательский ввод или текст ошибок
+func redactEvent(event *sentry.Event, r *redact.Redactor) {
+	event.Message = r.Scrub(event.Message)
+
+	for i := range event.Exception {
+		event.Exception[i].Value = r.Scrub(event.Exception[i].Value)
+	}
+
+	r.Map(event.Extra)
+	for _, ctx := range event.Contexts {
+		r.Map(ctx)
+	}
+	for k, v := range event.Tags {
+		event.Tags[k] = r.Value(k, v)
+	}
+
+	if event.Request != nil {
+		redactRequest(event.Request, r)
+	}
+
+	event.User.Email = ""
+	event.User.IPAddress = ""
+
+	for _, b := range event.Breadcrumbs {
+		redactBreadcrumb(b, r)
+	}
+}
+
+func redactRequest(req *sentry.Request, r *redact.Redactor) {
+	req.URL = r.Scrub(req.URL)
+	req.Data = r.Scrub(req.Data)
+	if req.Cookies != "" {
+		req.Cookies = redact.Mask
+	}
+
+	for k, v := range req.Headers {
+		req.Headers[k] = r.Value(k, v)
+	}
+	for k, v := range req.Env {
+		req.Env[k] = r.Value(k, v)
+	}
+
+	if req.QueryString != "" {
+		query, err := url.ParseQuery(req.QueryString)
+		if err != nil {
+			req.QueryString = redact.Mask
+			return
+		}
+		for k, values := range query {
+			for i, v := range values {
+				values[i] = r.Value(k, v)
+			}
+		}
+		req.QueryString = query.Encode()
+	}
+}
+
+func redactBreadcrumb(b *sentry.Breadcrumb, r *redact.Redactor) {
+	b.Message = r.Scrub(b.Message)
+	r.Map(b.Data)
+}
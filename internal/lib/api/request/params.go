@@ -0,0 +1,47 @@
+package request
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 500
+)
+
+// ParseID читает числовой параметр пути chi
+func ParseID(r *http.Request, param string) (uint, error) {
+	id, err := strconv.ParseUint(chi.URLParam(r, param), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s", param)
+	}
+	return uint(id), nil
+}
+
+// ParsePage читает параметры limit и offset.
+// limit по умолчанию DefaultPageLimit и не больше MaxPageLimit
+func ParsePage(r *http.Request) (int, int, error) {
+	limit, offset := DefaultPageLimit, 0
+
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return 0, 0, fmt.Errorf("invalid limit")
+		}
+		limit = min(n, MaxPageLimit)
+	}
+
+	if v := r.URL.Query().Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return 0, 0, fmt.Errorf("invalid offset")
+		}
+		offset = n
+	}
+
+	return limit, offset, nil
+}
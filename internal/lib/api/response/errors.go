@@ -3,6 +3,10 @@ package response
 import (
 	"fmt"
 	"net/http"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
 )
 
 // AppError представляет ошибку приложения
@@ -34,6 +38,9 @@ const (
 	ErrCodeUserNotFound        = 1002
 	ErrCodeUserAlreadyExists   = 1003
 	ErrCodeInvalidInput        = 1004
+	ErrCodeIdempotencyMismatch = 1005
+	ErrCodeRequestInProgress   = 1006
+	ErrCodeForbidden           = 1007
 	ErrCodeDatabaseError       = 2001
 	ErrCodeInternalServerError = 5001
 )
@@ -44,6 +51,9 @@ var CommonErrors = map[int]*AppError{
 	ErrCodeUserNotFound:        NewAppError(ErrCodeUserNotFound, "User not found", "", http.StatusNotFound),
 	ErrCodeUserAlreadyExists:   NewAppError(ErrCodeUserAlreadyExists, "User already exists", "", http.StatusConflict),
 	ErrCodeInvalidInput:        NewAppError(ErrCodeInvalidInput, "Invalid input data", "", http.StatusBadRequest),
+	ErrCodeIdempotencyMismatch: NewAppError(ErrCodeIdempotencyMismatch, "Idempotency key was already used with a different request", "", http.StatusUnprocessableEntity),
+	ErrCodeRequestInProgress:   NewAppError(ErrCodeRequestInProgress, "A request with this idempotency key is still in progress", "", http.StatusConflict),
+	ErrCodeForbidden:           NewAppError(ErrCodeForbidden, "Operation is not permitted", "", http.StatusForbidden),
 	ErrCodeDatabaseError:       NewAppError(ErrCodeDatabaseError, "Database operation failed", "", http.StatusInternalServerError),
 	ErrCodeInternalServerError: NewAppError(ErrCodeInternalServerError, "Internal server error", "", http.StatusInternalServerError),
 }
@@ -56,9 +66,53 @@ func GetCommonError(code int) *AppError {
 	return CommonErrors[ErrCodeInternalServerError]
 }
 
-// SendAppError отправляет ошибку приложения клиенту
+// SendAppError отправляет ошибку приложения клиенту вместе с ее кодом
+// Предопределенное сообщение отдается всегда, детали ошибок 5xx — только
+// если это разрешено SetExposeInternalErrors
 func SendAppError(w http.ResponseWriter, r *http.Request, appErr *AppError) {
-	SendError(w, r, appErr.StatusCode, appErr.Message, appErr.ErrorMessage)
+	errorMessage := appErr.ErrorMessage
+	if appErr.StatusCode >= http.StatusInternalServerError && !exposeInternalErrors.Load() {
+		errorMessage = ""
+	}
+
+	observeError(r, appErr.StatusCode, appErr.Code)
+
+	render.Status(r, appErr.StatusCode)
+	render.JSON(w, r, &ErrorResponse{
+		Code:         appErr.StatusCode,
+		ErrorCode:    appErr.Code,
+		Message:      appErr.Message,
+		ErrorMessage: errorMessage,
+		RequestID:    middleware.GetReqID(r.Context()),
+	})
+}
+
+// SendDomainError отправляет ошибку доменного слоя с кодом ошибки API,
+// подобранным DomainErrorCode. Текст ошибок 5xx скрывается, как в SendError
+func SendDomainError(w http.ResponseWriter, r *http.Request, err *domain.AppError) {
+	SendAppError(w, r, NewAppError(DomainErrorCode(err), DomainErrorMessage(err), "", err.Code))
+}
+
+// DomainErrorCode подбирает код ошибки API для ошибки доменного слоя
+func DomainErrorCode(err *domain.AppError) int {
+	switch err.Code {
+	case http.StatusBadRequest:
+		return ErrCodeValidationFailed
+	case http.StatusNotFound:
+		return ErrCodeUserNotFound
+	case http.StatusConflict:
+		return ErrCodeUserAlreadyExists
+	case http.StatusForbidden:
+		return ErrCodeForbidden
+	default:
+		return ErrCodeInternalServerError
+	}
+}
+
+// DomainErrorMessage текст ошибки доменного слоя для тела ответа,
+// например для результата отдельного элемента пакетной операции
+func DomainErrorMessage(err *domain.AppError) string {
+	return internalMessage(err.Code, err.Message)
 }
 
 // SendCommonError отправляет предопределенную ошибку по коду
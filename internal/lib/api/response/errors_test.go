@@ -0,0 +1,66 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/api/route"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func counterValue(t *testing.T, labels ...string) float64 {
+	t.Helper()
+
+	counter, ok := metrics.APIErrors.WithLabelValues(labels...).(prometheus.Counter)
+	if !ok {
+		t.Fatal("http_error_responses_total is not a Prometheus counter")
+	}
+
+	var m dto.Metric
+	if err := counter.Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetCounter().GetValue()
+}
+
+func TestSendDomainError(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         *domain.AppError
+		wantStatus  int
+		wantCode    int
+		wantMessage string
+	}{
+		{name: "not found", err: domain.NewNotFoundError("User not found"), wantStatus: http.StatusNotFound, wantCode: ErrCodeUserNotFound, wantMessage: "User not found"},
+		{name: "validation", err: domain.NewFieldValidationError("name", "The name should not be empty."), wantStatus: http.StatusBadRequest, wantCode: ErrCodeValidationFailed, wantMessage: "The name should not be empty."},
+		{name: "forbidden", err: domain.NewForbiddenError("denied"), wantStatus: http.StatusForbidden, wantCode: ErrCodeForbidden, wantMessage: "denied"},
+		{name: "internal hides message", err: domain.NewUnexpectedError("pq: relation users does not exist"), wantStatus: http.StatusInternalServerError, wantCode: ErrCodeInternalServerError, wantMessage: "Internal Server Error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			labels := []string{route.Unknown, strconv.Itoa(tt.wantStatus), strconv.Itoa(tt.wantCode)}
+			before := counterValue(t, labels...)
+
+			w := httptest.NewRecorder()
+			SendDomainError(w, httptest.NewRequest(http.MethodGet, "/api/v1/users/1", nil), tt.err)
+
+			var resp ErrorResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if w.Code != tt.wantStatus || resp.ErrorCode != tt.wantCode || resp.Message != tt.wantMessage {
+				t.Fatalf("response %d %+v, want %d with error_code %d and message %q", w.Code, resp, tt.wantStatus, tt.wantCode, tt.wantMessage)
+			}
+			if got := counterValue(t, labels...) - before; got != 1 {
+				t.Fatalf("http_error_responses_total%v increased by %v, want 1", labels, got)
+			}
+		})
+	}
+}
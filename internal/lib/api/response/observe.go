@@ -0,0 +1,40 @@
+package response
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/api/route"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/metrics"
+	"github.com/getsentry/sentry-go"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+// noErrorCode метка ошибок, отправленных без кода API
+const noErrorCode = "none"
+
+// observeError учитывает ответ с ошибкой в метрике и помечает статусом
+// и кодом ошибки текущий span, scope Sentry и транзакцию New Relic
+func observeError(r *http.Request, status, errorCode int) {
+	code := noErrorCode
+	if errorCode != 0 {
+		code = strconv.Itoa(errorCode)
+	}
+	statusCode := strconv.Itoa(status)
+
+	metrics.APIErrors.WithLabelValues(route.Pattern(r), statusCode, code).Inc()
+
+	ctx := r.Context()
+	if span := sentry.SpanFromContext(ctx); span != nil {
+		span.Status = sentry.HTTPtoSpanStatus(status)
+		span.SetTag("http.status_code", statusCode)
+		span.SetTag("error_code", code)
+	}
+	if hub := sentry.GetHubFromContext(ctx); hub != nil {
+		hub.Scope().SetTag("http.status_code", statusCode)
+		hub.Scope().SetTag("error_code", code)
+	}
+	if txn := newrelic.FromContext(ctx); txn != nil {
+		txn.AddAttribute("error_code", code)
+	}
+}
@@ -2,7 +2,9 @@ package response
 
 import (
 	"net/http"
+	"sync/atomic"
 
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 )
 
@@ -17,8 +19,10 @@ type Response struct {
 // ErrorResponse представляет структуру для ошибок
 type ErrorResponse struct {
 	Code         int    `json:"code"`
+	ErrorCode    int    `json:"error_code,omitempty"`
 	Message      string `json:"message"`
 	ErrorMessage string `json:"error,omitempty"`
+	RequestID    string `json:"request_id,omitempty"`
 }
 
 // SuccessResponse представляет структуру для успешных ответов
@@ -46,10 +50,37 @@ func NewSuccessResponse(code int, message string, data interface{}) *SuccessResp
 	}
 }
 
+// exposeInternalErrors разрешает отдавать клиенту детали ошибок 5xx
+var exposeInternalErrors atomic.Bool
+
+// SetExposeInternalErrors включает детали ошибок 5xx в ответах.
+// По умолчанию вместо них отдается стандартный текст статуса
+func SetExposeInternalErrors(expose bool) {
+	exposeInternalErrors.Store(expose)
+}
+
+// internalMessage скрывает текст ошибки 5xx: он может содержать
+// запросы к БД, значения параметров и адреса внутренних сервисов
+func internalMessage(code int, message string) string {
+	if code < http.StatusInternalServerError || exposeInternalErrors.Load() {
+		return message
+	}
+	return http.StatusText(code)
+}
+
 // SendError отправляет ошибку клиенту
 func SendError(w http.ResponseWriter, r *http.Request, code int, message, errorMessage string) {
+	if code >= http.StatusInternalServerError && !exposeInternalErrors.Load() {
+		message, errorMessage = http.StatusText(code), ""
+	}
+
+	resp := NewErrorResponse(code, message, errorMessage)
+	resp.RequestID = middleware.GetReqID(r.Context())
+
+	observeError(r, code, 0)
+
 	render.Status(r, code)
-	render.JSON(w, r, NewErrorResponse(code, message, errorMessage))
+	render.JSON(w, r, resp)
 }
 
 // SendSuccess отправляет успешный ответ клиенту
@@ -63,6 +94,11 @@ func SendBadRequest(w http.ResponseWriter, r *http.Request, message string) {
 	SendError(w, r, http.StatusBadRequest, message, "")
 }
 
+// SendUnauthorized отправляет ошибку 401
+func SendUnauthorized(w http.ResponseWriter, r *http.Request, message string) {
+	SendError(w, r, http.StatusUnauthorized, message, "")
+}
+
 // SendInternalServerError отправляет ошибку 500
 func SendInternalServerError(w http.ResponseWriter, r *http.Request, message string) {
 	SendError(w, r, http.StatusInternalServerError, message, "")
@@ -0,0 +1,33 @@
+package route
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Unknown метка запросов, не совпавших ни с одним маршрутом
+const Unknown = "unknown"
+
+// Pattern шаблон маршрута chi для меток метрик и трассировки. После
+// маршрутизации берется из контекста, для ответов из middleware до
+// маршрутизации ищется по дереву маршрутов. Несовпавшие запросы
+// объединяются в Unknown, чтобы не раздувать число рядов
+func Pattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return Unknown
+	}
+
+	if pattern := rctx.RoutePattern(); pattern != "" && pattern != "/*" {
+		return pattern
+	}
+	if rctx.Routes == nil {
+		return Unknown
+	}
+
+	if pattern := rctx.Routes.Find(chi.NewRouteContext(), r.Method, r.URL.Path); pattern != "" && pattern != "/*" {
+		return pattern
+	}
+	return Unknown
+}
@@ -0,0 +1,201 @@
+package dashboards
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DatasourceUID uid источника данных Prometheus из файла provisioning
+const DatasourceUID = "prometheus"
+
+const (
+	panelWidth  = 12
+	panelHeight = 8
+	gridWidth   = 24
+)
+
+// Dashboard модель дашборда Grafana в объеме, который нужен сервису
+type Dashboard struct {
+	UID           string     `json:"uid"`
+	Title         string     `json:"title"`
+	Tags          []string   `json:"tags"`
+	Timezone      string     `json:"timezone"`
+	SchemaVersion int        `json:"schemaVersion"`
+	Version       int        `json:"version"`
+	Refresh       string     `json:"refresh"`
+	Time          TimeRange  `json:"time"`
+	Templating    Templating `json:"templating"`
+	Panels        []Panel    `json:"panels"`
+}
+
+type TimeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type Templating struct {
+	List []Variable `json:"list"`
+}
+
+type Variable struct {
+	Name       string      `json:"name"`
+	Label      string      `json:"label,omitempty"`
+	Type       string      `json:"type"`
+	Query      string      `json:"query"`
+	Datasource *Datasource `json:"datasource,omitempty"`
+	Refresh    int         `json:"refresh,omitempty"`
+	IncludeAll bool        `json:"includeAll,omitempty"`
+	Multi      bool        `json:"multi,omitempty"`
+}
+
+type Datasource struct {
+	Type string `json:"type"`
+	UID  string `json:"uid"`
+}
+
+type Panel struct {
+	ID          int         `json:"id"`
+	Type        string      `json:"type"`
+	Title       string      `json:"title"`
+	Description string      `json:"description,omitempty"`
+	GridPos     GridPos     `json:"gridPos"`
+	Datasource  Datasource  `json:"datasource"`
+	Targets     []Target    `json:"targets"`
+	FieldConfig FieldConfig `json:"fieldConfig"`
+}
+
+type GridPos struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+type Target struct {
+	RefID        string `json:"refId"`
+	Expr         string `json:"expr"`
+	LegendFormat string `json:"legendFormat,omitempty"`
+	Exemplar     bool   `json:"exemplar,omitempty"`
+}
+
+type FieldConfig struct {
+	Defaults  FieldDefaults `json:"defaults"`
+	Overrides []any         `json:"overrides"`
+}
+
+type FieldDefaults struct {
+	Unit string `json:"unit,omitempty"`
+}
+
+// Query запрос панели. Метрики в Expr указываются с селектором
+// {job=~"$job"}: по нему Validate находит имена метрик
+type Query struct {
+	Expr     string
+	Legend   string
+	Exemplar bool
+}
+
+var datasource = Datasource{Type: "prometheus", UID: "${datasource}"}
+
+// New дашборд с переменными источника данных и job
+func New(uid, title string) *Dashboard {
+	return &Dashboard{
+		UID:           uid,
+		Title:         title,
+		Tags:          []string{"generated"},
+		Timezone:      "browser",
+		SchemaVersion: 39,
+		Version:       1,
+		Refresh:       "30s",
+		Time:          TimeRange{From: "now-1h", To: "now"},
+		Templating: Templating{List: []Variable{
+			{Name: "datasource", Label: "Data source", Type: "datasource", Query: "prometheus"},
+			{
+				Name:       "job",
+				Label:      "Job",
+				Type:       "query",
+				Query:      "label_values(up, job)",
+				Datasource: &datasource,
+				Refresh:    1,
+				IncludeAll: true,
+				Multi:      true,
+			},
+		}},
+	}
+}
+
+// Panel добавляет панель временного ряда. Панели располагаются по две
+// в строке в порядке добавления
+func (d *Dashboard) Panel(title, unit string, queries ...Query) *Dashboard {
+	n := len(d.Panels)
+
+	panel := Panel{
+		ID:    n + 1,
+		Type:  "timeseries",
+		Title: title,
+		GridPos: GridPos{
+			X: (n * panelWidth) % gridWidth,
+			Y: (n * panelWidth / gridWidth) * panelHeight,
+			W: panelWidth,
+			H: panelHeight,
+		},
+		Datasource:  datasource,
+		FieldConfig: FieldConfig{Defaults: FieldDefaults{Unit: unit}, Overrides: []any{}},
+	}
+	for i, q := range queries {
+		panel.Targets = append(panel.Targets, Target{
+			RefID:        string(rune('A' + i)),
+			Expr:         q.Expr,
+			LegendFormat: q.Legend,
+			Exemplar:     q.Exemplar,
+		})
+	}
+
+	d.Panels = append(d.Panels, panel)
+	return d
+}
+
+// metricPattern имя метрики перед селектором меток
+var metricPattern = regexp.MustCompile(`([a-zA-Z_:][a-zA-Z0-9_:]*)\{`)
+
+// histogramSuffixes ряды, которые Prometheus создает для гистограмм и summary
+var histogramSuffixes = []string{"_bucket", "_count", "_sum"}
+
+// Validate проверяет, что каждый запрос ссылается хотя бы на одну метрику
+// и все метрики есть среди known
+func Validate(dashboards []*Dashboard, known map[string]struct{}) error {
+	for _, d := range dashboards {
+		for _, p := range d.Panels {
+			for _, t := range p.Targets {
+				matches := metricPattern.FindAllStringSubmatch(t.Expr, -1)
+				if len(matches) == 0 {
+					return fmt.Errorf("%s/%s: query %q references no metric", d.UID, p.Title, t.Expr)
+				}
+
+				for _, m := range matches {
+					if !isKnown(m[1], known) {
+						return fmt.Errorf("%s/%s: unknown metric %s", d.UID, p.Title, m[1])
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func isKnown(name string, known map[string]struct{}) bool {
+	if _, ok := known[name]; ok {
+		return true
+	}
+
+	for _, suffix := range histogramSuffixes {
+		if base, ok := strings.CutSuffix(name, suffix); ok {
+			if _, ok := known[base]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package dashboards
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/metrics"
+)
+
+func TestServiceDashboardsUseKnownMetrics(t *testing.T) {
+	if err := Validate(Service(), metrics.Names()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	known := map[string]struct{}{
+		"http_requests_total":           {},
+		"http_request_duration_seconds": {},
+	}
+
+	tests := []struct {
+		name    string
+		expr    string
+		wantErr string
+	}{
+		{name: "known counter", expr: `sum(rate(http_requests_total{job=~"$job"}[5m]))`},
+		{name: "histogram series", expr: `histogram_quantile(0.99, sum by (le) (rate(http_request_duration_seconds_bucket{job=~"$job"}[5m])))`},
+		{name: "unknown metric", expr: `sum(rate(http_requests_totals{job=~"$job"}[5m]))`, wantErr: "unknown metric http_requests_totals"},
+		{name: "no selector", expr: `sum(rate(http_requests_total[5m]))`, wantErr: "references no metric"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := New("test", "Test").Panel("panel", "", Query{Expr: tt.expr})
+
+			err := Validate([]*Dashboard{d}, known)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
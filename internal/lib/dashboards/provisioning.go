@@ -0,0 +1,69 @@
+package dashboards
+
+// DashboardsPath каталог дашбордов внутри контейнера Grafana
+const DashboardsPath = "/var/lib/grafana/dashboards"
+
+// DatasourceProvisioning файл provisioning источников данных Grafana
+type DatasourceProvisioning struct {
+	APIVersion  int                 `yaml:"apiVersion"`
+	Datasources []ProvisionedSource `yaml:"datasources"`
+}
+
+type ProvisionedSource struct {
+	Name      string `yaml:"name"`
+	UID       string `yaml:"uid"`
+	Type      string `yaml:"type"`
+	Access    string `yaml:"access"`
+	URL       string `yaml:"url"`
+	IsDefault bool   `yaml:"isDefault"`
+	Editable  bool   `yaml:"editable"`
+}
+
+// DashboardProvisioning файл provisioning дашбордов Grafana
+type DashboardProvisioning struct {
+	APIVersion int                 `yaml:"apiVersion"`
+	Providers  []DashboardProvider `yaml:"providers"`
+}
+
+type DashboardProvider struct {
+	Name            string          `yaml:"name"`
+	Folder          string          `yaml:"folder"`
+	Type            string          `yaml:"type"`
+	DisableDeletion bool            `yaml:"disableDeletion"`
+	AllowUIUpdates  bool            `yaml:"allowUiUpdates"`
+	Options         ProviderOptions `yaml:"options"`
+}
+
+type ProviderOptions struct {
+	Path string `yaml:"path"`
+}
+
+// Datasources источник Prometheus из docker-compose
+func Datasources() DatasourceProvisioning {
+	return DatasourceProvisioning{
+		APIVersion: 1,
+		Datasources: []ProvisionedSource{{
+			Name:      "Prometheus",
+			UID:       DatasourceUID,
+			Type:      "prometheus",
+			Access:    "proxy",
+			URL:       "http://prometheus:9090",
+			IsDefault: true,
+		}},
+	}
+}
+
+// Providers дашборды читаются из DashboardsPath. Изменения из интерфейса
+// не сохраняются: дашборды правятся в коде и генерируются заново
+func Providers() DashboardProvisioning {
+	return DashboardProvisioning{
+		APIVersion: 1,
+		Providers: []DashboardProvider{{
+			Name:            "service",
+			Folder:          "Service",
+			Type:            "file",
+			DisableDeletion: true,
+			Options:         ProviderOptions{Path: DashboardsPath},
+		}},
+	}
+}
@@ -0,0 +1,135 @@
+package dashboards
+
+import "fmt"
+
+// job селектор, которым отмечается каждая метрика в запросах
+const job = `{job=~"$job"}`
+
+// sel добавляет к селектору job дополнительные условия
+func sel(matchers string) string {
+	return fmt.Sprintf(`{job=~"$job",%s}`, matchers)
+}
+
+// quantile запрос квантиля гистограммы с группировкой по by
+func quantile(q float64, metric, by string) Query {
+	return Query{
+		Expr:     fmt.Sprintf(`histogram_quantile(%v, sum by (le, %s) (rate(%s_bucket%s[$__rate_interval])))`, q, by, metric, job),
+		Legend:   fmt.Sprintf("p%v {{%s}}", q*100, by),
+		Exemplar: true,
+	}
+}
+
+// Service дашборды сервиса: RED по маршрутам, пул соединений и запросы
+// к БД, рантайм Go и бизнес-метрики
+func Service() []*Dashboard {
+	return []*Dashboard{red(), database(), runtime(), business()}
+}
+
+func red() *Dashboard {
+	return New("service-red", "Service / HTTP").
+		Panel("Request rate by route", "reqps", Query{
+			Expr:   `sum by (method, route) (rate(http_requests_total` + job + `[$__rate_interval]))`,
+			Legend: "{{method}} {{route}}",
+		}).
+		Panel("Error ratio by route", "percentunit", Query{
+			Expr: `sum by (method, route) (rate(http_requests_total` + sel(`status=~"5.."`) + `[$__rate_interval]))
+/
+sum by (method, route) (rate(http_requests_total` + job + `[$__rate_interval]))`,
+			Legend: "{{method}} {{route}}",
+		}).
+		Panel("Latency p50 / p95 / p99", "s",
+			quantile(0.5, "http_request_duration_seconds", "route"),
+			quantile(0.95, "http_request_duration_seconds", "route"),
+			quantile(0.99, "http_request_duration_seconds", "route"),
+		).
+		Panel("Responses by status", "reqps", Query{
+			Expr:   `sum by (status) (rate(http_requests_total` + job + `[$__rate_interval]))`,
+			Legend: "{{status}}",
+		}).
+		Panel("API errors by error code", "reqps", Query{
+			Expr:   `sum by (error_code, status) (rate(http_error_responses_total` + job + `[$__rate_interval]))`,
+			Legend: "{{error_code}} ({{status}})",
+		}).
+		Panel("Rate limit rejections and panics", "reqps",
+			Query{
+				Expr:   `sum by (route) (rate(http_rate_limit_rejections_total` + job + `[$__rate_interval]))`,
+				Legend: "rate limited {{route}}",
+			},
+			Query{
+				Expr:   `sum by (route) (rate(panics_total` + job + `[$__rate_interval]))`,
+				Legend: "panic {{route}}",
+			},
+		)
+}
+
+func database() *Dashboard {
+	return New("service-db", "Service / Database").
+		Panel("Connections", "short",
+			Query{Expr: `sum(go_sql_open_connections` + job + `)`, Legend: "open"},
+			Query{Expr: `sum(go_sql_in_use_connections` + job + `)`, Legend: "in use"},
+			Query{Expr: `sum(go_sql_idle_connections` + job + `)`, Legend: "idle"},
+			Query{Expr: `sum(go_sql_max_open_connections` + job + `)`, Legend: "max open"},
+		).
+		Panel("Waits for a connection", "s",
+			Query{Expr: `sum(rate(go_sql_wait_duration_seconds_total` + job + `[$__rate_interval]))`, Legend: "wait time per second"},
+		).
+		Panel("Query latency p95 by operation", "s",
+			quantile(0.95, "db_query_duration_seconds", "operation"),
+		).
+		Panel("Queries by outcome", "ops", Query{
+			Expr:   `sum by (operation, outcome) (rate(db_query_duration_seconds_count` + job + `[$__rate_interval]))`,
+			Legend: "{{operation}} {{outcome}}",
+		})
+}
+
+func runtime() *Dashboard {
+	return New("service-runtime", "Service / Runtime").
+		Panel("Goroutines", "short", Query{Expr: `sum by (instance) (go_goroutines` + job + `)`, Legend: "{{instance}}"}).
+		Panel("Heap in use", "bytes", Query{Expr: `sum by (instance) (go_memstats_heap_inuse_bytes` + job + `)`, Legend: "{{instance}}"}).
+		Panel("GC pause", "s", Query{
+			Expr:   `sum by (instance) (rate(go_gc_duration_seconds_sum` + job + `[$__rate_interval]))`,
+			Legend: "{{instance}}",
+		}).
+		Panel("CPU and resident memory", "short",
+			Query{Expr: `sum by (instance) (rate(process_cpu_seconds_total` + job + `[$__rate_interval]))`, Legend: "cpu {{instance}}"},
+			Query{Expr: `sum by (instance) (process_resident_memory_bytes` + job + `) / 1024 / 1024`, Legend: "rss MiB {{instance}}"},
+		).
+		Panel("Dropped log records", "short", Query{
+			Expr:   `sum by (sink, reason) (rate(log_records_dropped_total` + job + `[$__rate_interval]))`,
+			Legend: "{{sink}} {{reason}}",
+		})
+}
+
+func business() *Dashboard {
+	return New("service-business", "Service / Users").
+		Panel("Total users", "short", Query{Expr: `max(users_total` + job + `)`, Legend: "users"}).
+		Panel("User changes", "ops", Query{
+			Expr:   `sum by (operation) (rate(users_changed_total` + job + `[$__rate_interval]))`,
+			Legend: "{{operation}}",
+		}).
+		Panel("Validation failures by field", "ops", Query{
+			Expr:   `sum by (operation, field) (rate(user_validation_failures_total` + job + `[$__rate_interval]))`,
+			Legend: "{{operation}} {{field}}",
+		}).
+		Panel("Median age at creation", "short", Query{
+			Expr:   `histogram_quantile(0.5, sum by (le) (rate(user_age_at_creation_bucket` + job + `[$__rate_interval])))`,
+			Legend: "median age",
+		}).
+		Panel("Use case latency p95", "s", quantile(0.95, "usecase_duration_seconds", "method")).
+		Panel("Cache hit ratio", "percentunit", Query{
+			Expr: `sum by (cache) (rate(cache_requests_total` + sel(`result=~"hit|negative_hit"`) + `[$__rate_interval]))
+/
+sum by (cache) (rate(cache_requests_total` + job + `[$__rate_interval]))`,
+			Legend: "{{cache}}",
+		}).
+		Panel("Webhook deliveries", "ops",
+			Query{
+				Expr:   `sum by (outcome) (rate(webhook_delivery_duration_seconds_count` + job + `[$__rate_interval]))`,
+				Legend: "{{outcome}}",
+			},
+			Query{
+				Expr:   `sum(rate(webhook_deliveries_dead_total` + job + `[$__rate_interval]))`,
+				Legend: "dead",
+			},
+		)
+}
@@ -0,0 +1,86 @@
+package httpclient
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/metrics"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/requestid"
+	"github.com/getsentry/sentry-go"
+)
+
+// New http.Client для исходящих интеграций. name различает интеграции
+// в метриках и логах
+func New(name string, timeout time.Duration, log *slog.Logger) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: NewTransport(name, http.DefaultTransport, log),
+	}
+}
+
+// transport передает идентификатор запроса и заголовки трассировки Sentry,
+// пишет длительность вызова в метрики и логирует вызов
+type transport struct {
+	name string
+	next http.RoundTripper
+	log  *slog.Logger
+}
+
+// NewTransport оборачивает next. Если в контексте нет идентификатора
+// запроса (фоновые задачи), для вызова создается новый
+func NewTransport(name string, next http.RoundTripper, log *slog.Logger) http.RoundTripper {
+	return &transport{
+		name: name,
+		next: next,
+		log:  log.With(slog.String("component", "httpclient"), slog.String("client", name)),
+	}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	// RoundTrip не должен менять исходный запрос
+	req = req.Clone(ctx)
+
+	id := req.Header.Get(requestid.Header)
+	if id == "" {
+		id = requestid.FromContext(ctx)
+	}
+	if id == "" {
+		id = requestid.New()
+	}
+	req.Header.Set(requestid.Header, id)
+
+	if span := sentry.SpanFromContext(ctx); span != nil {
+		req.Header.Set(sentry.SentryTraceHeader, span.ToSentryTrace())
+		if baggage := span.ToBaggage(); baggage != "" {
+			req.Header.Set(sentry.SentryBaggageHeader, baggage)
+		}
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	elapsed := time.Since(start)
+
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	metrics.ObserveWithTrace(ctx, metrics.HTTPClientRequestDuration.WithLabelValues(t.name, req.Method, status), elapsed.Seconds())
+
+	attrs := []any{
+		slog.String("method", req.Method),
+		slog.String("host", req.URL.Host),
+		slog.String("status", status),
+		slog.Duration("duration", elapsed),
+	}
+	// request_id из контекста добавит логгер, отличающийся пишется явно
+	if id != requestid.FromContext(ctx) {
+		attrs = append(attrs, slog.String("outbound_request_id", id))
+	}
+	t.log.DebugContext(ctx, "outbound request", attrs...)
+
+	return resp, err
+}
@@ -0,0 +1,75 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+)
+
+// Server слушатель открывается при запуске, чтобы ошибка занятого порта
+// прервала старт. Остановка ждет завершения начатых запросов
+func (m *Manager) Server(name string, srv *http.Server) Hook {
+	return Hook{
+		Name: name,
+		OnStart: func(ctx context.Context) error {
+			ln, err := net.Listen("tcp", srv.Addr)
+			if err != nil {
+				return err
+			}
+
+			go func() {
+				if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					m.Fail(fmt.Errorf("%s: %w", name, err))
+				}
+			}()
+
+			m.log.Info("server started", slog.String("name", name), slog.String("address", ln.Addr().String()))
+			return nil
+		},
+		OnStop: srv.Shutdown,
+	}
+}
+
+// Worker фоновая задача, работающая до отмены своего контекста.
+// Остановка ждет возврата из run, но не дольше бюджета остановки
+func Worker(name string, run func(ctx context.Context)) Hook {
+	var (
+		cancel context.CancelFunc
+		done   = make(chan struct{})
+	)
+
+	return Hook{
+		Name: name,
+		OnStart: func(context.Context) error {
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(context.Background())
+
+			go func() {
+				defer close(done)
+				run(ctx)
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			cancel()
+
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+}
+
+// Closer освобождает ресурс при остановке
+func Closer(name string, close func() error) Hook {
+	return Hook{
+		Name:   name,
+		OnStop: func(context.Context) error { return close() },
+	}
+}
@@ -0,0 +1,165 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/config"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/api/response"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/logger/sl"
+)
+
+// Hook запуск и остановка одного компонента. Любая из функций может быть nil
+type Hook struct {
+	Name    string
+	OnStart func(ctx context.Context) error
+	OnStop  func(ctx context.Context) error
+}
+
+// Manager запускает компоненты в порядке регистрации
+// и останавливает в обратном
+type Manager struct {
+	cfg   config.Lifecycle
+	log   *slog.Logger
+	hooks []Hook
+
+	ready    atomic.Bool
+	failOnce sync.Once
+	failed   chan error
+}
+
+func New(cfg config.Lifecycle, log *slog.Logger) *Manager {
+	return &Manager{
+		cfg:    cfg,
+		log:    log.With(slog.String("component", "lifecycle")),
+		failed: make(chan error, 1),
+	}
+}
+
+// Append регистрирует компонент. Компоненты, от которых зависят другие,
+// регистрируются раньше: они запускаются первыми и останавливаются последними
+func (m *Manager) Append(hook Hook) {
+	m.hooks = append(m.hooks, hook)
+}
+
+// Fail сообщает о фатальной ошибке компонента, работающего в фоне.
+// Приложение начинает остановку так же, как по сигналу
+func (m *Manager) Fail(err error) {
+	m.failOnce.Do(func() { m.failed <- err })
+}
+
+// Ready true после запуска всех компонентов и до начала остановки
+func (m *Manager) Ready() bool {
+	return m.ready.Load()
+}
+
+// ReadyHandler отвечает 503, пока приложение не готово принимать трафик
+func (m *Manager) ReadyHandler(w http.ResponseWriter, r *http.Request) {
+	if !m.Ready() {
+		response.SendError(w, r, http.StatusServiceUnavailable, "not ready", "")
+		return
+	}
+	response.SendOK(w, r, "ready", nil)
+}
+
+// Run запускает компоненты и ждет отмены ctx или фатальной ошибки,
+// после чего останавливает их в пределах ShutdownTimeout
+func (m *Manager) Run(ctx context.Context) error {
+	if err := m.start(ctx); err != nil {
+		return err
+	}
+
+	m.ready.Store(true)
+	m.log.Info("application started", slog.Int("components", len(m.hooks)))
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+		m.log.Info("shutdown requested")
+	case runErr = <-m.failed:
+		m.log.Error("component failed, shutting down", sl.Err(runErr))
+	}
+
+	return errors.Join(runErr, m.stop())
+}
+
+// start при ошибке останавливает уже запущенные компоненты
+func (m *Manager) start(ctx context.Context) error {
+	for i, hook := range m.hooks {
+		if hook.OnStart == nil {
+			continue
+		}
+
+		m.log.Debug("starting component", slog.String("name", hook.Name))
+		if err := hook.OnStart(ctx); err != nil {
+			err = fmt.Errorf("start %s: %w", hook.Name, err)
+			m.log.Error("failed to start component", slog.String("name", hook.Name), sl.Err(err))
+
+			stopCtx, cancel := context.WithTimeout(context.Background(), m.cfg.ShutdownTimeout)
+			defer cancel()
+
+			return errors.Join(err, m.stopHooks(stopCtx, m.hooks[:i]))
+		}
+	}
+
+	return nil
+}
+
+// stop сначала снимает готовность и выжидает DrainDelay, чтобы балансировщик
+// перестал направлять запросы, и только затем закрывает слушатели.
+// Ожидание входит в общий бюджет ShutdownTimeout
+func (m *Manager) stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.cfg.ShutdownTimeout)
+	defer cancel()
+
+	m.ready.Store(false)
+
+	if m.cfg.DrainDelay > 0 {
+		m.log.Info("draining before shutdown", slog.Duration("delay", m.cfg.DrainDelay))
+
+		timer := time.NewTimer(m.cfg.DrainDelay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}
+
+	err := m.stopHooks(ctx, m.hooks)
+	if err != nil {
+		m.log.Error("application stopped with errors", sl.Err(err))
+		return err
+	}
+
+	m.log.Info("application stopped")
+	return nil
+}
+
+// stopHooks останавливает компоненты в обратном порядке. Ошибка одного
+// компонента не мешает остановить остальные
+func (m *Manager) stopHooks(ctx context.Context, hooks []Hook) error {
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hook := hooks[i]
+		if hook.OnStop == nil {
+			continue
+		}
+
+		started := time.Now()
+		if err := hook.OnStop(ctx); err != nil {
+			m.log.Error("failed to stop component", slog.String("name", hook.Name), sl.Err(err))
+			errs = append(errs, fmt.Errorf("stop %s: %w", hook.Name, err))
+			continue
+		}
+
+		m.log.Info("component stopped", slog.String("name", hook.Name), slog.Duration("took", time.Since(started)))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,119 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/metrics"
+)
+
+type asyncEntry struct {
+	ctx     context.Context
+	handler slog.Handler
+	record  slog.Record
+}
+
+// asyncQueue очередь одного приемника, которую разбирает отдельная горутина.
+// При переполнении записи отбрасываются, а не блокируют вызывающего
+type asyncQueue struct {
+	name    string
+	entries chan asyncEntry
+	done    chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// syncHandler приемник, часть записей которого нужно обработать
+// в горутине вызывающего: транзакция New Relic или hub Sentry
+// из контекста запроса к моменту разбора очереди могут быть завершены
+type syncHandler interface {
+	// handleSync возвращает true, если запись обработана и в очередь не попадает
+	handleSync(ctx context.Context, r slog.Record) bool
+}
+
+// AsyncHandler асинхронная обертка над приемником
+type AsyncHandler struct {
+	queue *asyncQueue
+	next  slog.Handler
+}
+
+// NewAsyncHandler size ограничивает число записей, ожидающих отправки
+func NewAsyncHandler(name string, next slog.Handler, size int) *AsyncHandler {
+	q := &asyncQueue{
+		name:    name,
+		entries: make(chan asyncEntry, max(size, 1)),
+		done:    make(chan struct{}),
+	}
+	go q.run()
+
+	return &AsyncHandler{queue: q, next: next}
+}
+
+func (h *AsyncHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle контекст передается без отмены: запись обрабатывается
+// уже после завершения запроса
+func (h *AsyncHandler) Handle(ctx context.Context, r slog.Record) error {
+	if sh, ok := h.next.(syncHandler); ok && sh.handleSync(ctx, r) {
+		return nil
+	}
+
+	q := h.queue
+
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		metrics.LogRecordsDropped.WithLabelValues(q.name, "closed").Inc()
+		return nil
+	}
+
+	select {
+	case q.entries <- asyncEntry{ctx: context.WithoutCancel(ctx), handler: h.next, record: r.Clone()}:
+	default:
+		metrics.LogRecordsDropped.WithLabelValues(q.name, "buffer_full").Inc()
+	}
+
+	return nil
+}
+
+func (h *AsyncHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &AsyncHandler{queue: h.queue, next: h.next.WithAttrs(attrs)}
+}
+
+func (h *AsyncHandler) WithGroup(name string) slog.Handler {
+	return &AsyncHandler{queue: h.queue, next: h.next.WithGroup(name)}
+}
+
+// Close перестает принимать записи и ждет отправки накопленных до отмены ctx
+func (h *AsyncHandler) Close(ctx context.Context) error {
+	q := h.queue
+
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.entries)
+	}
+	q.mu.Unlock()
+
+	select {
+	case <-q.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (q *asyncQueue) run() {
+	defer close(q.done)
+
+	for e := range q.entries {
+		if err := e.handler.Handle(e.ctx, e.record); err != nil {
+			metrics.LogSinkErrors.WithLabelValues(q.name).Inc()
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package logger
+
+import (
+	"log/slog"
+	"time"
+)
+
+// flatAttrs атрибуты для приемников без вложенных структур:
+// ключи групп объединяются через точку (request.id)
+type flatAttrs struct {
+	prefix string
+	values map[string]any
+}
+
+func (f flatAttrs) withAttrs(attrs []slog.Attr) flatAttrs {
+	values := make(map[string]any, len(f.values)+len(attrs))
+	for k, v := range f.values {
+		values[k] = v
+	}
+	for _, a := range attrs {
+		addFlat(values, f.prefix, a)
+	}
+	return flatAttrs{prefix: f.prefix, values: values}
+}
+
+func (f flatAttrs) withGroup(name string) flatAttrs {
+	if name == "" {
+		return f
+	}
+	return flatAttrs{prefix: f.prefix + name + ".", values: f.values}
+}
+
+// record атрибуты обработчика вместе с атрибутами записи
+func (f flatAttrs) record(r slog.Record) map[string]any {
+	values := make(map[string]any, len(f.values)+r.NumAttrs())
+	for k, v := range f.values {
+		values[k] = v
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		addFlat(values, f.prefix, a)
+		return true
+	})
+	return values
+}
+
+func addFlat(dst map[string]any, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		groupPrefix := prefix
+		if a.Key != "" {
+			groupPrefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			addFlat(dst, groupPrefix, ga)
+		}
+		return
+	}
+
+	dst[prefix+a.Key] = flatValue(a.Value)
+}
+
+func flatValue(v slog.Value) any {
+	switch v.Kind() {
+	case slog.KindTime:
+		return v.Time().Format(time.RFC3339Nano)
+	case slog.KindDuration:
+		return v.Duration().String()
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			return err.Error()
+		}
+		return v.String()
+	default:
+		return v.Any()
+	}
+}
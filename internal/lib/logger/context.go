@@ -0,0 +1,139 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/requestid"
+	"github.com/getsentry/sentry-go"
+	"github.com/go-chi/chi/v5"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+// Атрибуты, которые contextHandler берет из контекста запроса
+const (
+	RequestIDKey = "request_id"
+	TraceIDKey   = "trace_id"
+	SpanIDKey    = "span_id"
+	PrincipalKey = "principal"
+	RouteKey     = "route"
+)
+
+type loggerKey struct{}
+
+// NewContext сохраняет логгер запроса в контексте
+func NewContext(ctx context.Context, log *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, log)
+}
+
+// FromContext логгер запроса или slog.Default, если его нет. Записи
+// возвращенного логгера обогащаются данными ctx и при вызовах без
+// контекста (log.Info), поэтому его не нужно передавать дальше
+func FromContext(ctx context.Context) *slog.Logger {
+	log, ok := ctx.Value(loggerKey{}).(*slog.Logger)
+	if !ok {
+		log = slog.Default()
+	}
+
+	return Bind(ctx, log)
+}
+
+// Bind привязывает ctx к логгеру компонента так же, как FromContext
+func Bind(ctx context.Context, log *slog.Logger) *slog.Logger {
+	return slog.New(&boundHandler{next: log.Handler(), ctx: ctx})
+}
+
+// boundHandler подставляет сохраненный контекст в вызовы без контекста
+type boundHandler struct {
+	next slog.Handler
+	ctx  context.Context
+}
+
+func (h *boundHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(h.context(ctx), level)
+}
+
+func (h *boundHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.next.Handle(h.context(ctx), r)
+}
+
+func (h *boundHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &boundHandler{next: h.next.WithAttrs(attrs), ctx: h.ctx}
+}
+
+func (h *boundHandler) WithGroup(name string) slog.Handler {
+	return &boundHandler{next: h.next.WithGroup(name), ctx: h.ctx}
+}
+
+// context slog передает context.Background, если вызов сделан без контекста
+func (h *boundHandler) context(ctx context.Context) context.Context {
+	if ctx == nil || ctx == context.Background() {
+		return h.ctx
+	}
+	return ctx
+}
+
+// contextHandler добавляет к записи данные запроса из контекста:
+// идентификатор запроса, трассировку, вызывающего и шаблон маршрута
+type contextHandler struct {
+	next slog.Handler
+}
+
+func newContextHandler(next slog.Handler) *contextHandler {
+	return &contextHandler{next: next}
+}
+
+func (h *contextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx == nil {
+		return h.next.Handle(ctx, r)
+	}
+
+	attrs := contextAttrs(ctx)
+	if len(attrs) > 0 {
+		r = r.Clone()
+		r.AddAttrs(attrs...)
+	}
+
+	return h.next.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{next: h.next.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{next: h.next.WithGroup(name)}
+}
+
+func contextAttrs(ctx context.Context) []slog.Attr {
+	var attrs []slog.Attr
+
+	if id := requestid.FromContext(ctx); id != "" {
+		attrs = append(attrs, slog.String(RequestIDKey, id))
+	}
+
+	if span := sentry.SpanFromContext(ctx); span != nil {
+		attrs = append(attrs, slog.String(TraceIDKey, span.TraceID.String()), slog.String(SpanIDKey, span.SpanID.String()))
+	} else if txn := newrelic.FromContext(ctx); txn != nil {
+		if md := txn.GetTraceMetadata(); md.TraceID != "" {
+			attrs = append(attrs, slog.String(TraceIDKey, md.TraceID), slog.String(SpanIDKey, md.SpanID))
+		}
+	}
+
+	if principal, ok := domain.PrincipalFromContext(ctx); ok {
+		attrs = append(attrs, slog.Any(PrincipalKey, principal))
+	}
+
+	if rctx := chi.RouteContext(ctx); rctx != nil {
+		if pattern := rctx.RoutePattern(); pattern != "" {
+			attrs = append(attrs, slog.String(RouteKey, pattern))
+		}
+	}
+
+	return attrs
+}
@@ -0,0 +1,75 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// Sink дополнительный приемник записей. Level применяется поверх общих
+// уровней из Levels; nil не ограничивает
+type Sink struct {
+	Name    string
+	Handler slog.Handler
+	Level   slog.Leveler
+}
+
+// multiHandler передает запись каждому приемнику, уровень которого ее пропускает
+type multiHandler struct {
+	sinks []Sink
+}
+
+func newMultiHandler(sinks ...Sink) slog.Handler {
+	if len(sinks) == 1 && sinks[0].Level == nil {
+		return sinks[0].Handler
+	}
+	return &multiHandler{sinks: sinks}
+}
+
+func (h *multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, s := range h.sinks {
+		if s.enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle ошибка одного приемника не мешает записи в остальные
+func (h *multiHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, s := range h.sinks {
+		if !s.enabled(ctx, r.Level) {
+			continue
+		}
+		if err := s.Handler.Handle(ctx, r.Clone()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h *multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	sinks := make([]Sink, len(h.sinks))
+	for i, s := range h.sinks {
+		s.Handler = s.Handler.WithAttrs(attrs)
+		sinks[i] = s
+	}
+	return &multiHandler{sinks: sinks}
+}
+
+func (h *multiHandler) WithGroup(name string) slog.Handler {
+	sinks := make([]Sink, len(h.sinks))
+	for i, s := range h.sinks {
+		s.Handler = s.Handler.WithGroup(name)
+		sinks[i] = s
+	}
+	return &multiHandler{sinks: sinks}
+}
+
+func (s Sink) enabled(ctx context.Context, level slog.Level) bool {
+	if s.Level != nil && level < s.Level.Level() {
+		return false
+	}
+	return s.Handler.Enabled(ctx, level)
+}
@@ -0,0 +1,45 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"math"
+)
+
+// minLevel уровень для нижележащих обработчиков: фильтрацию выполняет levelHandler
+const minLevel = slog.Level(math.MinInt)
+
+// levelHandler пропускает записи по уровню компонента,
+// заданного атрибутом ComponentKey в logger.With
+type levelHandler struct {
+	next      slog.Handler
+	levels    *Levels
+	component string
+}
+
+func newLevelHandler(next slog.Handler, levels *Levels) *levelHandler {
+	return &levelHandler{next: next, levels: levels}
+}
+
+func (h *levelHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.levels.Level(h.component)
+}
+
+func (h *levelHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.next.Handle(ctx, r)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	component := h.component
+	for _, a := range attrs {
+		if a.Key == ComponentKey {
+			component = a.Value.String()
+		}
+	}
+
+	return &levelHandler{next: h.next.WithAttrs(attrs), levels: h.levels, component: component}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{next: h.next.WithGroup(name), levels: h.levels, component: h.component}
+}
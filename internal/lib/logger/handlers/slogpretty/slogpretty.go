@@ -1,98 +1,320 @@
 package slogpretty
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
-	stdLog "log"
-
 	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
 )
 
+const timeFormat = "[15:04:05.000]"
+
 type PrettyHandlerOptions struct {
 	SlogOpts *slog.HandlerOptions
+	// NoColor отключает цвета. Они также отключаются, если задана
+	// переменная NO_COLOR или вывод идет не в терминал
+	NoColor bool
 }
 
+// PrettyHandler выводит запись одной строкой, а атрибуты — JSON объектом
+// с группами в виде вложенных объектов и ключами в алфавитном порядке
 type PrettyHandler struct {
-	opts PrettyHandlerOptions
-	slog.Handler
-	l     *stdLog.Logger
-	attrs []slog.Attr
+	opts   slog.HandlerOptions
+	colors palette
+
+	mu  *sync.Mutex
+	out io.Writer
+
+	// attrs атрибуты из WithAttrs, уже разложенные по группам
+	attrs  map[string]any
+	groups []string
 }
 
 func (opts PrettyHandlerOptions) NewPrettyHandler(
 	out io.Writer,
 ) *PrettyHandler {
 	h := &PrettyHandler{
-		Handler: slog.NewJSONHandler(out, opts.SlogOpts),
-		l:       stdLog.New(out, "", 0),
+		colors: newPalette(!opts.NoColor && colorSupported(out)),
+		mu:     &sync.Mutex{},
+		out:    out,
+		attrs:  map[string]any{},
+	}
+
+	if opts.SlogOpts != nil {
+		h.opts = *opts.SlogOpts
 	}
 
 	return h
 }
 
+func (h *PrettyHandler) Enabled(_ context.Context, level slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if h.opts.Level != nil {
+		minLevel = h.opts.Level.Level()
+	}
+	return level >= minLevel
+}
+
 func (h *PrettyHandler) Handle(_ context.Context, r slog.Record) error {
-	level := r.Level.String() + ":"
+	fields := cloneTree(h.attrs)
 
-	switch r.Level {
-	case slog.LevelDebug:
-		level = color.MagentaString(level)
-	case slog.LevelInfo:
-		level = color.BlueString(level)
-	case slog.LevelWarn:
-		level = color.YellowString(level)
-	case slog.LevelError:
-		level = color.RedString(level)
+	if r.NumAttrs() > 0 {
+		target := groupMap(fields, h.groups)
+		r.Attrs(func(a slog.Attr) bool {
+			h.addAttr(target, h.groups, a)
+			return true
+		})
 	}
+	pruneEmpty(fields)
 
-	fields := make(map[string]interface{}, r.NumAttrs())
+	buf := &bytes.Buffer{}
 
-	r.Attrs(func(a slog.Attr) bool {
-		fields[a.Key] = a.Value.Any()
+	if !r.Time.IsZero() {
+		buf.WriteString(r.Time.Format(timeFormat))
+		buf.WriteByte(' ')
+	}
 
-		return true
-	})
+	buf.WriteString(h.colors.level(r.Level))
+	buf.WriteByte(' ')
 
-	for _, a := range h.attrs {
-		fields[a.Key] = a.Value.Any()
+	if h.opts.AddSource && r.PC != 0 {
+		buf.WriteString(h.colors.source.Sprint(shortSource(r.PC)))
+		buf.WriteByte(' ')
 	}
 
-	var b []byte
-	var err error
+	buf.WriteString(h.colors.message.Sprint(r.Message))
 
 	if len(fields) > 0 {
-		b, err = json.MarshalIndent(fields, "", "  ")
+		b, err := json.MarshalIndent(fields, "", "  ")
 		if err != nil {
-			return err
+			// значение не сериализуется в JSON (канал, функция, ошибка
+			// MarshalJSON): запись выводится без форматирования, а не теряется
+			b = fmt.Appendf(nil, "%v", fields)
 		}
+		buf.WriteByte(' ')
+		buf.WriteString(h.colors.attrs.Sprint(string(b)))
 	}
 
-	timeStr := r.Time.Format("[15:05:05.000]")
-	msg := color.CyanString(r.Message)
+	buf.WriteByte('\n')
 
-	h.l.Println(
-		timeStr,
-		level,
-		msg,
-		color.WhiteString(string(b)),
-	)
+	h.mu.Lock()
+	defer h.mu.Unlock()
 
-	return nil
+	_, err := h.out.Write(buf.Bytes())
+	return err
 }
 
 func (h *PrettyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	return &PrettyHandler{
-		Handler: h.Handler,
-		l:       h.l,
-		attrs:   attrs,
+	if len(attrs) == 0 {
+		return h
+	}
+
+	h2 := h.clone()
+	target := groupMap(h2.attrs, h2.groups)
+	for _, a := range attrs {
+		h2.addAttr(target, h2.groups, a)
 	}
+
+	return h2
 }
 
 func (h *PrettyHandler) WithGroup(name string) slog.Handler {
-	return &PrettyHandler{
-		Handler: h.Handler.WithGroup(name),
-		l:       h.l,
+	if name == "" {
+		return h
+	}
+
+	h2 := h.clone()
+	h2.groups = append(slices.Clip(h2.groups), name)
+
+	return h2
+}
+
+func (h *PrettyHandler) clone() *PrettyHandler {
+	h2 := *h
+	h2.attrs = cloneTree(h.attrs)
+	return &h2
+}
+
+// addAttr добавляет атрибут в dst по правилам slog: пустые атрибуты
+// пропускаются, группа без ключа встраивается в текущий уровень
+func (h *PrettyHandler) addAttr(dst map[string]any, groups []string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+
+	if a.Value.Kind() != slog.KindGroup && h.opts.ReplaceAttr != nil {
+		a = h.opts.ReplaceAttr(groups, a)
+		a.Value = a.Value.Resolve()
+	}
+
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	if a.Value.Kind() != slog.KindGroup {
+		dst[a.Key] = attrValue(a.Value)
+		return
+	}
+
+	attrs := a.Value.Group()
+	if len(attrs) == 0 {
+		return
+	}
+
+	target := dst
+	if a.Key != "" {
+		sub, ok := dst[a.Key].(map[string]any)
+		if !ok {
+			sub = map[string]any{}
+			dst[a.Key] = sub
+		}
+		target = sub
+		groups = append(slices.Clip(groups), a.Key)
+	}
+
+	for _, ga := range attrs {
+		h.addAttr(target, groups, ga)
+	}
+}
+
+func attrValue(v slog.Value) any {
+	switch v.Kind() {
+	case slog.KindTime:
+		return v.Time().Format(time.RFC3339Nano)
+	case slog.KindDuration:
+		return v.Duration().String()
+	case slog.KindAny:
+		switch x := v.Any().(type) {
+		case error:
+			return x.Error()
+		case json.Marshaler:
+			return x
+		case fmt.Stringer:
+			return x.String()
+		default:
+			return x
+		}
+	default:
+		return v.Any()
 	}
 }
+
+// groupMap возвращает объект для атрибутов внутри групп, создавая его при необходимости
+func groupMap(root map[string]any, groups []string) map[string]any {
+	m := root
+	for _, g := range groups {
+		sub, ok := m[g].(map[string]any)
+		if !ok {
+			sub = map[string]any{}
+			m[g] = sub
+		}
+		m = sub
+	}
+	return m
+}
+
+func cloneTree(m map[string]any) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		if sub, ok := v.(map[string]any); ok {
+			v = cloneTree(sub)
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// pruneEmpty удаляет группы без атрибутов, как это делают обработчики slog
+func pruneEmpty(m map[string]any) {
+	for k, v := range m {
+		if sub, ok := v.(map[string]any); ok {
+			pruneEmpty(sub)
+			if len(sub) == 0 {
+				delete(m, k)
+			}
+		}
+	}
+}
+
+// shortSource каталог, файл и строка вызова: handlers/user.go:42
+func shortSource(pc uintptr) string {
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	if frame.File == "" {
+		return ""
+	}
+
+	dir, file := filepath.Split(frame.File)
+	return filepath.Join(filepath.Base(dir), file) + ":" + strconv.Itoa(frame.Line)
+}
+
+type palette struct {
+	levels  map[slog.Level]*color.Color
+	message *color.Color
+	source  *color.Color
+	attrs   *color.Color
+}
+
+func newPalette(enabled bool) palette {
+	p := palette{
+		levels: map[slog.Level]*color.Color{
+			slog.LevelDebug: color.New(color.FgMagenta),
+			slog.LevelInfo:  color.New(color.FgBlue),
+			slog.LevelWarn:  color.New(color.FgYellow),
+			slog.LevelError: color.New(color.FgRed),
+		},
+		message: color.New(color.FgCyan),
+		source:  color.New(color.Faint),
+		attrs:   color.New(color.FgWhite),
+	}
+
+	for _, c := range append([]*color.Color{p.message, p.source, p.attrs}, slices.Collect(maps.Values(p.levels))...) {
+		if enabled {
+			c.EnableColor()
+		} else {
+			c.DisableColor()
+		}
+	}
+
+	return p
+}
+
+// level уровни между стандартными окрашиваются цветом ближайшего меньшего
+func (p palette) level(level slog.Level) string {
+	text := level.String() + ":"
+
+	switch {
+	case level >= slog.LevelError:
+		return p.levels[slog.LevelError].Sprint(text)
+	case level >= slog.LevelWarn:
+		return p.levels[slog.LevelWarn].Sprint(text)
+	case level >= slog.LevelInfo:
+		return p.levels[slog.LevelInfo].Sprint(text)
+	default:
+		return p.levels[slog.LevelDebug].Sprint(text)
+	}
+}
+
+// colorSupported цвета выводятся только в терминал и если не задан NO_COLOR
+func colorSupported(out io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+
+	f, ok := out.(*os.File)
+	if !ok {
+		return false
+	}
+
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
@@ -0,0 +1,144 @@
+package slogpretty
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"testing/slogtest"
+	"time"
+)
+
+// recordWriter сохраняет каждую запись отдельно: Handle пишет запись
+// одним вызовом Write, а JSON атрибутов занимает несколько строк
+type recordWriter struct {
+	records []string
+}
+
+func (w *recordWriter) Write(p []byte) (int, error) {
+	w.records = append(w.records, string(p))
+	return len(p), nil
+}
+
+// parseRecord разбирает "[время] УРОВЕНЬ: сообщение {атрибуты}" в формат slogtest
+func parseRecord(t *testing.T, record string) map[string]any {
+	t.Helper()
+
+	m := map[string]any{}
+	rest := strings.TrimSuffix(record, "\n")
+
+	if strings.HasPrefix(rest, "[") {
+		ts, after, _ := strings.Cut(rest[1:], "] ")
+		m[slog.TimeKey] = ts
+		rest = after
+	}
+
+	level, rest, _ := strings.Cut(rest, ": ")
+	m[slog.LevelKey] = level
+
+	msg, attrs, found := strings.Cut(rest, " {\n")
+	m[slog.MessageKey] = msg
+	if found {
+		if err := json.Unmarshal([]byte("{\n"+attrs), &m); err != nil {
+			t.Fatalf("attributes of %q: %v", record, err)
+		}
+	}
+
+	return m
+}
+
+func newTestHandler(w *recordWriter, opts *slog.HandlerOptions) *PrettyHandler {
+	return PrettyHandlerOptions{SlogOpts: opts, NoColor: true}.NewPrettyHandler(w)
+}
+
+func TestSlogtest(t *testing.T) {
+	w := &recordWriter{}
+	h := newTestHandler(w, nil)
+
+	err := slogtest.TestHandler(h, func() []map[string]any {
+		out := make([]map[string]any, 0, len(w.records))
+		for _, r := range w.records {
+			out = append(out, parseRecord(t, r))
+		}
+		return out
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNestedGroups(t *testing.T) {
+	w := &recordWriter{}
+	log := slog.New(newTestHandler(w, nil))
+
+	log.WithGroup("request").With(slog.String("id", "42")).WithGroup("user").
+		Info("done", slog.String("name", "alice"), slog.Group("limits", slog.Int("max", 5)))
+
+	got := parseRecord(t, w.records[0])
+	want := `{"id":"42","user":{"limits":{"max":5},"name":"alice"}}`
+	b, _ := json.Marshal(got["request"])
+	if string(b) != want {
+		t.Fatalf("request = %s, want %s", b, want)
+	}
+}
+
+func TestTimeFormat(t *testing.T) {
+	w := &recordWriter{}
+	h := newTestHandler(w, nil)
+
+	ts := time.Date(2024, 3, 1, 9, 5, 7, 123456789, time.UTC)
+	if err := h.Handle(context.Background(), slog.NewRecord(ts, slog.LevelWarn, "slow query", 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "[09:05:07.123] WARN: slow query\n"; w.records[0] != want {
+		t.Fatalf("record = %q, want %q", w.records[0], want)
+	}
+}
+
+func TestAddSource(t *testing.T) {
+	w := &recordWriter{}
+	slog.New(newTestHandler(w, &slog.HandlerOptions{AddSource: true})).Info("started")
+
+	if !strings.Contains(w.records[0], "slogpretty/slogpretty_test.go:") {
+		t.Fatalf("record = %q, want the caller's file and line", w.records[0])
+	}
+}
+
+// TestMarshalFallback значение, которое не сериализуется в JSON,
+// не приводит к потере записи
+func TestMarshalFallback(t *testing.T) {
+	w := &recordWriter{}
+	h := newTestHandler(w, nil)
+
+	r := slog.NewRecord(time.Time{}, slog.LevelInfo, "event", 0)
+	r.AddAttrs(slog.Any("ch", make(chan int)), slog.String("k", "v"))
+	if err := h.Handle(context.Background(), r); err != nil {
+		t.Fatalf("Handle() = %v", err)
+	}
+
+	if len(w.records) != 1 || !strings.Contains(w.records[0], "event") || !strings.Contains(w.records[0], "k:v") {
+		t.Fatalf("records = %q, want the record printed with %%v", w.records)
+	}
+}
+
+func TestNoColor(t *testing.T) {
+	var buf bytes.Buffer
+	if newPalette(true).level(slog.LevelInfo) == "INFO:" {
+		t.Fatal("enabled palette does not color levels")
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if colorSupported(os.Stdout) {
+		t.Fatal("colorSupported() = true with NO_COLOR set")
+	}
+
+	h := PrettyHandlerOptions{}.NewPrettyHandler(&buf)
+	slog.New(h).Error("failed", slog.String("k", "v"))
+	if strings.Contains(buf.String(), "\x1b[") {
+		t.Fatalf("output %q contains color codes", buf.String())
+	}
+}
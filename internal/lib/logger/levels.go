@@ -0,0 +1,206 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/config"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/logger/sl"
+)
+
+// ComponentKey атрибут, по которому выбирается уровень компонента
+const ComponentKey = "component"
+
+// Levels уровни логирования, изменяемые во время работы:
+// общий уровень и переопределения для отдельных компонентов
+type Levels struct {
+	global     slog.LevelVar
+	components atomic.Pointer[map[string]slog.Level]
+
+	mu sync.Mutex
+	// base уровень, к которому возвращается временный режим
+	base     slog.Level
+	revert   *time.Timer
+	revertAt time.Time
+}
+
+func NewLevels(level slog.Level) *Levels {
+	l := &Levels{base: level}
+	l.global.Set(level)
+	l.components.Store(&map[string]slog.Level{})
+	return l
+}
+
+// Level уровень для компонента; без переопределения — общий
+func (l *Levels) Level(component string) slog.Level {
+	if component != "" {
+		if level, ok := (*l.components.Load())[component]; ok {
+			return level
+		}
+	}
+	return l.global.Level()
+}
+
+// SetGlobal меняет общий уровень и отменяет временный режим
+func (l *Levels) SetGlobal(level slog.Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.stopRevert()
+	l.base = level
+	l.global.Set(level)
+}
+
+// SetGlobalFor меняет общий уровень на время d, после чего
+// возвращает прежний. Повторный вызов продлевает режим
+func (l *Levels) SetGlobalFor(level slog.Level, d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.stopRevert()
+	l.global.Set(level)
+	l.revertAt = time.Now().Add(d)
+
+	var timer *time.Timer
+	timer = time.AfterFunc(d, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if l.revert != timer {
+			return
+		}
+		l.revert = nil
+		l.revertAt = time.Time{}
+		l.global.Set(l.base)
+	})
+	l.revert = timer
+}
+
+// SetComponent переопределяет уровень компонента
+func (l *Levels) SetComponent(component string, level slog.Level) {
+	l.updateComponents(func(m map[string]slog.Level) { m[component] = level })
+}
+
+// ResetComponent возвращает компонент к общему уровню
+func (l *Levels) ResetComponent(component string) {
+	l.updateComponents(func(m map[string]slog.Level) { delete(m, component) })
+}
+
+// Apply заменяет общий уровень и все переопределения значениями из конфигурации.
+// Пустой cfg.Level означает уровень по умолчанию для окружения
+func (l *Levels) Apply(cfg config.Log, fallback slog.Level) error {
+	level := fallback
+	if cfg.Level != "" {
+		var err error
+		if level, err = ParseLevel(cfg.Level); err != nil {
+			return err
+		}
+	}
+
+	components := make(map[string]slog.Level, len(cfg.Components))
+	for component, value := range cfg.Components {
+		componentLevel, err := ParseLevel(value)
+		if err != nil {
+			return fmt.Errorf("component %s: %w", component, err)
+		}
+		components[component] = componentLevel
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.stopRevert()
+	l.base = level
+	l.global.Set(level)
+	l.components.Store(&components)
+	return nil
+}
+
+// LevelsState текущие уровни для отображения.
+// RevertAt задан, пока действует временный уровень
+type LevelsState struct {
+	Global     string            `json:"global"`
+	Components map[string]string `json:"components"`
+	RevertAt   *time.Time        `json:"revert_at,omitempty"`
+}
+
+func (l *Levels) State() LevelsState {
+	state := LevelsState{
+		Global:     l.global.Level().String(),
+		Components: make(map[string]string),
+	}
+
+	for component, level := range *l.components.Load() {
+		state.Components[component] = level.String()
+	}
+
+	l.mu.Lock()
+	if !l.revertAt.IsZero() {
+		revertAt := l.revertAt
+		state.RevertAt = &revertAt
+	}
+	l.mu.Unlock()
+
+	return state
+}
+
+// ReloadOnSignal по SIGHUP перечитывает уровни из конфигурации
+// до отмены ctx
+func (l *Levels) ReloadOnSignal(ctx context.Context, log *slog.Logger, fallback slog.Level, load func() (config.Log, error)) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			cfg, err := load()
+			if err == nil {
+				err = l.Apply(cfg, fallback)
+			}
+			if err != nil {
+				log.Error("failed to reload log levels", sl.Err(err))
+				continue
+			}
+
+			log.Info("log levels reloaded", slog.Any("levels", l.State()))
+		}
+	}
+}
+
+// ParseLevel принимает имена уровней slog: debug, info, warn, error,
+// в том числе со смещением (debug-4)
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("invalid log level %q", s)
+	}
+	return level, nil
+}
+
+func (l *Levels) updateComponents(fn func(map[string]slog.Level)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	components := maps.Clone(*l.components.Load())
+	fn(components)
+	l.components.Store(&components)
+}
+
+func (l *Levels) stopRevert() {
+	if l.revert != nil {
+		l.revert.Stop()
+		l.revert = nil
+	}
+	l.revertAt = time.Time{}
+}
@@ -4,7 +4,9 @@ import (
 	"log/slog"
 	"os"
 
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/config"
 	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/logger/handlers/slogpretty"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/redact"
 )
 
 const (
@@ -14,39 +16,57 @@ const (
 )
 
 // New creates a new slog.Logger based on the provided environment.
-// It sets up the logger with different handlers and levels depending on the environment.
-func New(env string) *slog.Logger {
-	var log *slog.Logger
+// The level defaults to debug for local/dev and info otherwise, can be
+// overridden by cfg and changed at runtime through the returned Levels.
+// Records are enriched with the request ID from the context, sampled when
+// sampler is not nil, redacted, then written to stdout and fanned out to
+// the additional sinks.
+func New(env string, cfg config.Log, redactor *redact.Redactor, sampler *Sampler, sinks ...Sink) (*slog.Logger, *Levels, error) {
+	levels := NewLevels(DefaultLevel(env))
+	if err := levels.Apply(cfg, DefaultLevel(env)); err != nil {
+		return nil, nil, err
+	}
+
+	var handler slog.Handler
 	switch env {
 	case envLocal:
-		log = setupPrettySlog()
-	case envDev:
-		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
-		)
-	case envProd:
-		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}),
-		)
+		handler = setupPrettyHandler()
 	default:
-		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}),
-		)
+		handler = slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: minLevel})
 	}
 
-	return log
+	handler = newMultiHandler(append([]Sink{{Name: "stdout", Handler: handler}}, sinks...)...)
+
+	handler = newRedactHandler(handler, redactor)
+
+	if sampler != nil {
+		handler = newSampleHandler(handler, sampler)
+	}
+
+	handler = newContextHandler(handler)
+
+	return slog.New(newLevelHandler(handler, levels)), levels, nil
 }
 
-// setupPrettySlog initializes a pretty logger using slogpretty handler
-// with debug level logging.
-func setupPrettySlog() *slog.Logger {
+// DefaultLevel returns the level used when the configuration does not set one.
+func DefaultLevel(env string) slog.Level {
+	switch env {
+	case envLocal, envDev:
+		return slog.LevelDebug
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// setupPrettyHandler initializes a pretty handler using slogpretty.
+// Local output includes the caller's file and line.
+func setupPrettyHandler() slog.Handler {
 	opts := slogpretty.PrettyHandlerOptions{
 		SlogOpts: &slog.HandlerOptions{
-			Level: slog.LevelDebug,
+			Level:     minLevel,
+			AddSource: true,
 		},
 	}
 
-	handler := opts.NewPrettyHandler(os.Stdout)
-
-	return slog.New(handler)
+	return opts.NewPrettyHandler(os.Stdout)
 }
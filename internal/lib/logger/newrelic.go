@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+// newRelicHandler пересылает записи в New Relic Logs. Записи запроса
+// сохраняются в его транзакции (logs in context) и связываются с трассой
+type newRelicHandler struct {
+	app   *newrelic.Application
+	attrs flatAttrs
+}
+
+func newNewRelicHandler(app *newrelic.Application) *newRelicHandler {
+	return &newRelicHandler{app: app}
+}
+
+func (h *newRelicHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *newRelicHandler) Handle(ctx context.Context, r slog.Record) error {
+	if !h.handleSync(ctx, r) {
+		h.app.RecordLog(h.logData(r))
+	}
+	return nil
+}
+
+// handleSync транзакция принимает записи только до End,
+// поэтому запись в нее не откладывается в очередь
+func (h *newRelicHandler) handleSync(ctx context.Context, r slog.Record) bool {
+	txn := newrelic.FromContext(ctx)
+	if txn == nil {
+		return false
+	}
+
+	txn.RecordLog(h.logData(r))
+	return true
+}
+
+func (h *newRelicHandler) logData(r slog.Record) newrelic.LogData {
+	return newrelic.LogData{
+		Timestamp:  r.Time.UnixMilli(),
+		Severity:   r.Level.String(),
+		Message:    r.Message,
+		Attributes: h.attrs.record(r),
+	}
+}
+
+func (h *newRelicHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &newRelicHandler{app: h.app, attrs: h.attrs.withAttrs(attrs)}
+}
+
+func (h *newRelicHandler) WithGroup(name string) slog.Handler {
+	return &newRelicHandler{app: h.app, attrs: h.attrs.withGroup(name)}
+}
@@ -0,0 +1,100 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/redact"
+)
+
+// redactHandler скрывает значения чувствительных ключей и очищает строки
+// до передачи записи приемникам, поэтому данные не попадают ни в один из них.
+// Из значений slog.Any очищаются ошибки, map[string]any и http.Header;
+// структуры передаются как есть, их нужно логировать через LogValue
+type redactHandler struct {
+	next     slog.Handler
+	redactor *redact.Redactor
+}
+
+func newRedactHandler(next slog.Handler, redactor *redact.Redactor) *redactHandler {
+	return &redactHandler{next: next, redactor: redactor}
+}
+
+func (h *redactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactHandler) Handle(ctx context.Context, r slog.Record) error {
+	clean := slog.NewRecord(r.Time, r.Level, h.redactor.Scrub(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		clean.AddAttrs(h.attr(a))
+		return true
+	})
+
+	return h.next.Handle(ctx, clean)
+}
+
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clean := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		clean[i] = h.attr(a)
+	}
+
+	return &redactHandler{next: h.next.WithAttrs(clean), redactor: h.redactor}
+}
+
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{next: h.next.WithGroup(name), redactor: h.redactor}
+}
+
+func (h *redactHandler) attr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+
+	if a.Key != "" && h.redactor.SensitiveKey(a.Key) {
+		return slog.String(a.Key, redact.Mask)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, h.redactor.Scrub(a.Value.String()))
+	case slog.KindGroup:
+		group := a.Value.Group()
+		clean := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			clean[i] = h.attr(ga)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(clean...)}
+	case slog.KindAny:
+		switch v := a.Value.Any().(type) {
+		case error:
+			return slog.String(a.Key, h.redactor.Scrub(v.Error()))
+		case map[string]any:
+			// Map очищает на месте, а map принадлежит вызывающему
+			clean := cloneMap(v)
+			h.redactor.Map(clean)
+			return slog.Any(a.Key, clean)
+		case http.Header:
+			clean := make(http.Header, len(v))
+			for name, values := range v {
+				for _, value := range values {
+					clean[name] = append(clean[name], h.redactor.Value(name, value))
+				}
+			}
+			return slog.Any(a.Key, clean)
+		}
+	}
+
+	return a
+}
+
+func cloneMap(m map[string]any) map[string]any {
+	clean := make(map[string]any, len(m))
+	for k, v := range m {
+		if nested, ok := v.(map[string]any); ok {
+			v = cloneMap(nested)
+		}
+		clean[k] = v
+	}
+	return clean
+}
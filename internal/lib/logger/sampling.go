@@ -0,0 +1,217 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/config"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/metrics"
+)
+
+// samplerSink имя в метрике отброшенных записей
+const samplerSink = "sampler"
+
+type sampleKey struct {
+	level   slog.Level
+	message string
+}
+
+// sampleWindow счетчики одинаковых записей за текущий интервал. handler —
+// корневой обработчик без атрибутов и групп записи, через него выводится сводка
+type sampleWindow struct {
+	start      time.Time
+	count      int
+	suppressed int
+	handler    slog.Handler
+}
+
+// Sampler ограничивает число одинаковых записей (уровень и сообщение)
+// не ниже dedupLevel за интервал и выборочно пропускает записи по долям
+// для уровней. О подавленных записях сообщает одна сводка по окончании
+// интервала
+type Sampler struct {
+	interval   time.Duration
+	burst      int
+	dedupLevel slog.Level
+	ratios     map[slog.Level]float64
+
+	mu      sync.Mutex
+	windows map[sampleKey]*sampleWindow
+}
+
+// NewSampler возвращает nil, если семплирование выключено
+func NewSampler(cfg config.LogSampling) (*Sampler, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	if cfg.Interval <= 0 {
+		return nil, fmt.Errorf("sampling interval must be positive")
+	}
+
+	dedupLevel, err := ParseLevel(cfg.DedupLevel)
+	if err != nil {
+		return nil, fmt.Errorf("sampling dedup level: %w", err)
+	}
+
+	ratios := make(map[slog.Level]float64, len(cfg.Ratios))
+	for name, ratio := range cfg.Ratios {
+		level, err := ParseLevel(name)
+		if err != nil {
+			return nil, fmt.Errorf("sampling ratio: %w", err)
+		}
+		if ratio < 0 || ratio > 1 {
+			return nil, fmt.Errorf("sampling ratio for %s must be between 0 and 1", name)
+		}
+		ratios[level] = ratio
+	}
+
+	return &Sampler{
+		interval:   cfg.Interval,
+		burst:      max(cfg.Burst, 1),
+		dedupLevel: dedupLevel,
+		ratios:     ratios,
+		windows:    make(map[sampleKey]*sampleWindow),
+	}, nil
+}
+
+// Run выводит сводки по истекшим интервалам, даже если одинаковые записи
+// больше не приходят, и оставшиеся сводки при отмене ctx
+func (s *Sampler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.flush(time.Time{})
+			return
+		case now := <-ticker.C:
+			s.flush(now)
+		}
+	}
+}
+
+// flush закрывает интервалы, начатые не позже now-interval.
+// Нулевой now закрывает все интервалы
+func (s *Sampler) flush(now time.Time) {
+	var summaries []sampleSummary
+
+	s.mu.Lock()
+	for key, w := range s.windows {
+		if !now.IsZero() && now.Sub(w.start) < s.interval {
+			continue
+		}
+		if w.suppressed > 0 {
+			summaries = append(summaries, sampleSummary{key: key, window: *w})
+		}
+		delete(s.windows, key)
+	}
+	s.mu.Unlock()
+
+	for _, summary := range summaries {
+		summary.emit()
+	}
+}
+
+// sample true, если запись нужно передать дальше. Вторым значением
+// возвращается сводка по предыдущему интервалу этой записи, если он
+// закрылся с подавленными записями
+func (s *Sampler) sample(r slog.Record, root slog.Handler) (bool, *sampleSummary) {
+	if ratio, ok := s.ratios[r.Level]; ok && rand.Float64() >= ratio {
+		metrics.LogRecordsDropped.WithLabelValues(samplerSink, "sampled").Inc()
+		return false, nil
+	}
+	if r.Level < s.dedupLevel {
+		return true, nil
+	}
+
+	now := r.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	key := sampleKey{level: r.Level, message: r.Message}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var summary *sampleSummary
+	w, ok := s.windows[key]
+	if ok && now.Sub(w.start) >= s.interval {
+		if w.suppressed > 0 {
+			summary = &sampleSummary{key: key, window: *w}
+		}
+		ok = false
+	}
+	if !ok {
+		w = &sampleWindow{start: now, handler: root}
+		s.windows[key] = w
+	}
+
+	w.count++
+	if w.count > s.burst {
+		w.suppressed++
+		metrics.LogRecordsDropped.WithLabelValues(samplerSink, "duplicate").Inc()
+		return false, summary
+	}
+
+	return true, summary
+}
+
+type sampleSummary struct {
+	key    sampleKey
+	window sampleWindow
+}
+
+// emit сводка содержит только ключ и число подавленных записей: атрибуты
+// первой записи к остальным не относятся. Уровень не выше warn, чтобы
+// сводка о повторяющейся ошибке не создавала еще одно событие об ошибке
+func (s sampleSummary) emit() {
+	r := slog.NewRecord(time.Now(), min(s.key.level, slog.LevelWarn), fmt.Sprintf("%d records suppressed", s.window.suppressed), 0)
+	r.AddAttrs(
+		slog.String("suppressed_level", s.key.level.String()),
+		slog.String("suppressed_msg", s.key.message),
+		slog.Int("suppressed", s.window.suppressed),
+	)
+	// сводка не относится к запросу, в котором закрылся интервал
+	_ = s.window.handler.Handle(context.Background(), r)
+}
+
+// sampleHandler пропускает запись в next, только если ее разрешил Sampler.
+// root — обработчик без WithAttrs и WithGroup для сводок
+type sampleHandler struct {
+	next    slog.Handler
+	root    slog.Handler
+	sampler *Sampler
+}
+
+func newSampleHandler(next slog.Handler, sampler *Sampler) *sampleHandler {
+	return &sampleHandler{next: next, root: next, sampler: sampler}
+}
+
+func (h *sampleHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *sampleHandler) Handle(ctx context.Context, r slog.Record) error {
+	pass, summary := h.sampler.sample(r, h.root)
+	if summary != nil {
+		summary.emit()
+	}
+	if !pass {
+		return nil
+	}
+
+	return h.next.Handle(ctx, r)
+}
+
+func (h *sampleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &sampleHandler{next: h.next.WithAttrs(attrs), root: h.root, sampler: h.sampler}
+}
+
+func (h *sampleHandler) WithGroup(name string) slog.Handler {
+	return &sampleHandler{next: h.next.WithGroup(name), root: h.root, sampler: h.sampler}
+}
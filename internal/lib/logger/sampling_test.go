@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/config"
+)
+
+// TestSamplerSummary сводка выводится без атрибутов подавленных записей
+// и с уровнем не выше warn
+func TestSamplerSummary(t *testing.T) {
+	sampler, err := NewSampler(config.LogSampling{Enabled: true, Interval: time.Minute, Burst: 1, DedupLevel: "warn"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	log := slog.New(newSampleHandler(slog.NewJSONHandler(&buf, nil), sampler))
+
+	for range 3 {
+		log.WithGroup("req").With(slog.String("user_id", "7")).Error("db is down")
+	}
+	sampler.flush(time.Time{})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d records, want the first one and a summary: %q", len(lines), lines)
+	}
+
+	var summary map[string]any
+	if err := json.Unmarshal([]byte(lines[1]), &summary); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]any{
+		"level":            "WARN",
+		"msg":              "2 records suppressed",
+		"suppressed_level": "ERROR",
+		"suppressed_msg":   "db is down",
+		"suppressed":       float64(2),
+	}
+	delete(summary, "time")
+	if len(summary) != len(want) {
+		t.Fatalf("summary = %v, want %v", summary, want)
+	}
+	for k, v := range want {
+		if summary[k] != v {
+			t.Fatalf("summary = %v, want %v", summary, want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// SentryReportedKey атрибут записи об ошибке, уже отправленной в Sentry
+// напрямую: приемник не создает по ней второе событие
+const SentryReportedKey = "sentry_reported"
+
+// SentryReported помечает запись атрибутом SentryReportedKey
+func SentryReported() slog.Attr {
+	return slog.Bool(SentryReportedKey, true)
+}
+
+// sentryHandler отправляет записи не ниже eventLevel как события,
+// а остальные — как breadcrumbs, которые попадут в следующее событие
+// запроса. Breadcrumbs добавляются только в hub запроса: в общем hub
+// они копились бы от всех запросов и фоновых задач
+type sentryHandler struct {
+	eventLevel slog.Level
+	attrs      flatAttrs
+}
+
+func newSentryHandler(eventLevel slog.Level) *sentryHandler {
+	return &sentryHandler{eventLevel: eventLevel}
+}
+
+func (h *sentryHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *sentryHandler) Handle(ctx context.Context, r slog.Record) error {
+	if h.handleSync(ctx, r) {
+		return nil
+	}
+
+	hub := sentry.GetHubFromContext(ctx)
+	attrs := h.attrs.record(r)
+
+	if reported, _ := attrs[SentryReportedKey].(bool); reported {
+		return nil
+	}
+
+	if hub == nil {
+		hub = sentry.CurrentHub()
+	}
+
+	event := sentry.NewEvent()
+	event.Level = sentryLevel(r.Level)
+	event.Message = r.Message
+	event.Timestamp = r.Time
+	event.Extra = attrs
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	if component, ok := attrs[ComponentKey].(string); ok {
+		event.Tags = map[string]string{ComponentKey: component}
+	}
+
+	hub.CaptureEvent(event)
+	return nil
+}
+
+// handleSync breadcrumb добавляется сразу: из очереди он мог бы попасть
+// в hub уже после события, к которому относится, или после конца запроса
+func (h *sentryHandler) handleSync(ctx context.Context, r slog.Record) bool {
+	if r.Level >= h.eventLevel {
+		return false
+	}
+
+	if hub := sentry.GetHubFromContext(ctx); hub != nil {
+		hub.AddBreadcrumb(&sentry.Breadcrumb{
+			Category:  "log",
+			Message:   r.Message,
+			Level:     sentryLevel(r.Level),
+			Data:      h.attrs.record(r),
+			Timestamp: r.Time,
+		}, nil)
+	}
+	return true
+}
+
+func (h *sentryHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &sentryHandler{eventLevel: h.eventLevel, attrs: h.attrs.withAttrs(attrs)}
+}
+
+func (h *sentryHandler) WithGroup(name string) slog.Handler {
+	return &sentryHandler{eventLevel: h.eventLevel, attrs: h.attrs.withGroup(name)}
+}
+
+func sentryLevel(level slog.Level) sentry.Level {
+	switch {
+	case level >= slog.LevelError:
+		return sentry.LevelError
+	case level >= slog.LevelWarn:
+		return sentry.LevelWarning
+	case level >= slog.LevelInfo:
+		return sentry.LevelInfo
+	default:
+		return sentry.LevelDebug
+	}
+}
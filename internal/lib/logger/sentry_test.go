@@ -0,0 +1,32 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// TestSentryBreadcrumbsBypassQueue breadcrumb попадает в hub запроса
+// сразу, даже если очередь приемника уже закрыта
+func TestSentryBreadcrumbsBypassQueue(t *testing.T) {
+	hub := sentry.NewHub(nil, sentry.NewScope())
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+
+	h := NewAsyncHandler("sentry", newSentryHandler(slog.LevelError), 1)
+	if err := h.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	slog.New(h).With(slog.String("user_id", "7")).InfoContext(ctx, "user loaded")
+
+	event := hub.Scope().ApplyToEvent(&sentry.Event{Timestamp: time.Now()}, nil, nil)
+	if event == nil || len(event.Breadcrumbs) != 1 {
+		t.Fatalf("event = %+v, want one breadcrumb", event)
+	}
+	if b := event.Breadcrumbs[0]; b.Message != "user loaded" || b.Data["user_id"] != "7" {
+		t.Fatalf("breadcrumb = %+v, want the log record with its attributes", b)
+	}
+}
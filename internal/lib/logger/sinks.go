@@ -0,0 +1,99 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/config"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Sinks приемники логов помимо stdout. Каждый работает через
+// собственную очередь, поэтому медленный приемник не задерживает запросы
+type Sinks struct {
+	sinks  []Sink
+	queues []*AsyncHandler
+	file   *lumberjack.Logger
+}
+
+// NewSinks sentryEnabled и newRelicApp показывают, инициализирована ли
+// телеметрия: без нее соответствующие приемники не создаются
+func NewSinks(cfg config.LogSinks, sentryEnabled bool, newRelicApp *newrelic.Application) (*Sinks, error) {
+	s := &Sinks{}
+
+	if cfg.File.Enabled {
+		level, err := parseSinkLevel(cfg.File.Level)
+		if err != nil {
+			return nil, fmt.Errorf("file sink: %w", err)
+		}
+
+		s.file = &lumberjack.Logger{
+			Filename:   cfg.File.Path,
+			MaxSize:    cfg.File.MaxSizeMB,
+			MaxBackups: cfg.File.MaxBackups,
+			MaxAge:     cfg.File.MaxAgeDays,
+			Compress:   cfg.File.Compress,
+		}
+		s.add("file", slog.NewJSONHandler(s.file, &slog.HandlerOptions{Level: minLevel}), level, cfg.BufferSize)
+	}
+
+	if cfg.Sentry.Enabled && sentryEnabled {
+		eventLevel, err := ParseLevel(cfg.Sentry.EventLevel)
+		if err != nil {
+			return nil, fmt.Errorf("sentry sink: %w", err)
+		}
+		breadcrumbLevel, err := ParseLevel(cfg.Sentry.BreadcrumbLevel)
+		if err != nil {
+			return nil, fmt.Errorf("sentry sink: %w", err)
+		}
+
+		s.add("sentry", newSentryHandler(eventLevel), min(breadcrumbLevel, eventLevel), cfg.BufferSize)
+	}
+
+	if cfg.NewRelic.Enabled && newRelicApp != nil {
+		level, err := parseSinkLevel(cfg.NewRelic.Level)
+		if err != nil {
+			return nil, fmt.Errorf("new relic sink: %w", err)
+		}
+
+		s.add("new_relic", newNewRelicHandler(newRelicApp), level, cfg.BufferSize)
+	}
+
+	return s, nil
+}
+
+// List приемники для logger.New
+func (s *Sinks) List() []Sink {
+	return s.sinks
+}
+
+// Close отправляет накопленные записи и закрывает файл
+func (s *Sinks) Close(ctx context.Context) error {
+	var errs []error
+	for _, q := range s.queues {
+		errs = append(errs, q.Close(ctx))
+	}
+
+	if s.file != nil {
+		errs = append(errs, s.file.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *Sinks) add(name string, handler slog.Handler, level slog.Leveler, bufferSize int) {
+	queue := NewAsyncHandler(name, handler, bufferSize)
+	s.queues = append(s.queues, queue)
+	s.sinks = append(s.sinks, Sink{Name: name, Handler: queue, Level: level})
+}
+
+// parseSinkLevel пустой уровень не ограничивает приемник
+func parseSinkLevel(s string) (slog.Leveler, error) {
+	if s == "" {
+		return nil, nil
+	}
+	return ParseLevel(s)
+}
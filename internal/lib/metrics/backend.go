@@ -0,0 +1,134 @@
+package metrics
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/config"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Бэкенды метрик
+const (
+	BackendPrometheus = "prometheus"
+	BackendStatsD     = "statsd"
+)
+
+// Counter, Gauge и Observer — операции, которые инструментирование
+// выполняет над метриками. Их реализуют и метрики Prometheus, и StatsD
+type Counter interface {
+	Inc()
+	Add(float64)
+}
+
+type Gauge interface {
+	Set(float64)
+	Inc()
+	Dec()
+	Add(float64)
+}
+
+type Observer interface {
+	Observe(float64)
+}
+
+// statsd активный клиент StatsD. nil означает бэкенд Prometheus
+var statsd atomic.Pointer[StatsD]
+
+// Setup выбирает бэкенд метрик. Для StatsD возвращается клиент, который
+// нужно закрыть при остановке, для Prometheus — nil. Метрики объявлены
+// до вызова Setup и до него пишутся в Prometheus
+func Setup(cfg config.Metrics) (*StatsD, error) {
+	switch cfg.Backend {
+	case "", BackendPrometheus:
+		statsd.Store(nil)
+		return nil, nil
+	case BackendStatsD:
+		client, err := NewStatsD(cfg.StatsD)
+		if err != nil {
+			return nil, err
+		}
+		statsd.Store(client)
+		return client, nil
+	default:
+		return nil, fmt.Errorf("unknown metrics backend %q", cfg.Backend)
+	}
+}
+
+// CounterVec счетчик с метками в выбранном бэкенде
+type CounterVec struct {
+	name   string
+	labels []string
+	prom   *prometheus.CounterVec
+}
+
+func newCounterVec(opts prometheus.CounterOpts, labels []string) *CounterVec {
+	return &CounterVec{name: opts.Name, labels: labels, prom: factory.NewCounterVec(opts, labels)}
+}
+
+func (v *CounterVec) WithLabelValues(values ...string) Counter {
+	if s := statsd.Load(); s != nil {
+		return s.counter(v.name, v.labels, values)
+	}
+	return v.prom.WithLabelValues(values...)
+}
+
+// HistogramVec гистограмма с метками в выбранном бэкенде
+type HistogramVec struct {
+	name   string
+	labels []string
+	prom   *prometheus.HistogramVec
+}
+
+func newHistogramVec(opts prometheus.HistogramOpts, labels []string) *HistogramVec {
+	return &HistogramVec{name: opts.Name, labels: labels, prom: factory.NewHistogramVec(opts, labels)}
+}
+
+// WithLabelValues для Prometheus возвращает prometheus.Observer, который
+// поддерживает exemplars (см. ObserveWithTrace)
+func (v *HistogramVec) WithLabelValues(values ...string) Observer {
+	if s := statsd.Load(); s != nil {
+		return s.observer(v.name, v.labels, values)
+	}
+	return v.prom.WithLabelValues(values...)
+}
+
+// Histogram гистограмма без меток в выбранном бэкенде
+type Histogram struct {
+	name string
+	prom prometheus.Histogram
+}
+
+func newHistogram(opts prometheus.HistogramOpts) *Histogram {
+	return &Histogram{name: opts.Name, prom: factory.NewHistogram(opts)}
+}
+
+func (h *Histogram) Observe(value float64) {
+	if s := statsd.Load(); s != nil {
+		s.observer(h.name, nil, nil).Observe(value)
+		return
+	}
+	h.prom.Observe(value)
+}
+
+// GaugeMetric значение без меток в выбранном бэкенде
+type GaugeMetric struct {
+	name string
+	prom prometheus.Gauge
+}
+
+func newGauge(opts prometheus.GaugeOpts) *GaugeMetric {
+	return &GaugeMetric{name: opts.Name, prom: factory.NewGauge(opts)}
+}
+
+func (g *GaugeMetric) gauge() Gauge {
+	if s := statsd.Load(); s != nil {
+		return s.gauge(g.name, nil, nil)
+	}
+	return g.prom
+}
+
+func (g *GaugeMetric) Set(value float64) { g.gauge().Set(value) }
+func (g *GaugeMetric) Inc()              { g.gauge().Inc() }
+func (g *GaugeMetric) Dec()              { g.gauge().Dec() }
+func (g *GaugeMetric) Add(value float64) { g.gauge().Add(value) }
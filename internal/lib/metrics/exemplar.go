@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TraceIDLabel метка exemplar с идентификатором трассировки
+const TraceIDLabel = "trace_id"
+
+// ObserveWithTrace записывает значение в гистограмму и прикрепляет к нему
+// exemplar с trace_id текущего span, чтобы из всплеска на графике можно было
+// перейти к трассировке. Без span или в StatsD значение записывается как обычно
+func ObserveWithTrace(ctx context.Context, o Observer, value float64) {
+	traceID := TraceID(ctx)
+	if traceID == "" {
+		o.Observe(value)
+		return
+	}
+
+	eo, ok := o.(prometheus.ExemplarObserver)
+	if !ok {
+		o.Observe(value)
+		return
+	}
+	eo.ObserveWithExemplar(value, prometheus.Labels{TraceIDLabel: traceID})
+}
+
+// TraceID идентификатор трассировки из span Sentry или транзакции New Relic
+func TraceID(ctx context.Context) string {
+	if span := sentry.SpanFromContext(ctx); span != nil {
+		return span.TraceID.String()
+	}
+	if txn := newrelic.FromContext(ctx); txn != nil {
+		return txn.GetTraceMetadata().TraceID
+	}
+	return ""
+}
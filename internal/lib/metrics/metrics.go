@@ -0,0 +1,159 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Метрики доставки вебхуков
+var (
+	WebhookDeliveryDuration = newHistogramVec(prometheus.HistogramOpts{
+		Name:    "webhook_delivery_duration_seconds",
+		Help:    "Duration of webhook delivery attempts.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"event", "outcome"})
+
+	WebhookDeliveryFailures = newCounterVec(prometheus.CounterOpts{
+		Name: "webhook_delivery_failures_total",
+		Help: "Number of failed webhook delivery attempts.",
+	}, []string{"event", "reason"})
+
+	WebhookDeliveriesDead = newCounterVec(prometheus.CounterOpts{
+		Name: "webhook_deliveries_dead_total",
+		Help: "Number of webhook deliveries moved to the dead-letter state.",
+	}, []string{"event"})
+)
+
+// Метрики ограничения частоты запросов
+var (
+	RateLimitRejections = newCounterVec(prometheus.CounterOpts{
+		Name: "http_rate_limit_rejections_total",
+		Help: "Number of requests rejected by the rate limiter.",
+	}, []string{"route", "key_type"})
+)
+
+// Метрики аутентификации и авторизации
+var (
+	AuthAttempts = newCounterVec(prometheus.CounterOpts{
+		Name: "http_auth_attempts_total",
+		Help: "Number of authentication attempts by method and result.",
+	}, []string{"method", "result"})
+
+	AuthzDenied = newCounterVec(prometheus.CounterOpts{
+		Name: "authz_denied_total",
+		Help: "Number of operations denied by the authorization policy.",
+	}, []string{"resource", "operation"})
+)
+
+// Метрики кэша
+var (
+	CacheRequests = newCounterVec(prometheus.CounterOpts{
+		Name: "cache_requests_total",
+		Help: "Number of cache lookups by result (hit, negative_hit, miss).",
+	}, []string{"cache", "result"})
+)
+
+// Метрики приемников логов
+var (
+	LogRecordsDropped = newCounterVec(prometheus.CounterOpts{
+		Name: "log_records_dropped_total",
+		Help: "Number of log records dropped by a sink.",
+	}, []string{"sink", "reason"})
+
+	LogSinkErrors = newCounterVec(prometheus.CounterOpts{
+		Name: "log_sink_errors_total",
+		Help: "Number of log records a sink failed to write.",
+	}, []string{"sink"})
+)
+
+// Метрики ответов API с ошибками. error_code — код ошибки API
+// (ErrCodeUserNotFound и т.д.) или none, если он не задан
+var (
+	APIErrors = newCounterVec(prometheus.CounterOpts{
+		Name: "http_error_responses_total",
+		Help: "Number of API error responses by route, HTTP status and error code.",
+	}, []string{"route", "status", "error_code"})
+)
+
+// Метрики паник в обработчиках
+var (
+	Panics = newCounterVec(prometheus.CounterOpts{
+		Name: "panics_total",
+		Help: "Number of panics recovered in HTTP handlers.",
+	}, []string{"route"})
+)
+
+// Бизнес-метрики пользователей
+var (
+	UsersChanged = newCounterVec(prometheus.CounterOpts{
+		Name: "users_changed_total",
+		Help: "Number of users created, updated or deleted through the API.",
+	}, []string{"operation"})
+
+	UserValidationFailures = newCounterVec(prometheus.CounterOpts{
+		Name: "user_validation_failures_total",
+		Help: "Number of rejected user changes by operation and invalid field.",
+	}, []string{"operation", "field"})
+
+	UserAgeAtCreation = newHistogram(prometheus.HistogramOpts{
+		Name:    "user_age_at_creation",
+		Help:    "Age of users at the moment they are created.",
+		Buckets: prometheus.LinearBuckets(0, 10, 11),
+	})
+
+	UsersTotal = newGauge(prometheus.GaugeOpts{
+		Name: "users_total",
+		Help: "Total number of users, refreshed periodically from the database.",
+	})
+
+	UseCaseDuration = newHistogramVec(prometheus.HistogramOpts{
+		Name:    "usecase_duration_seconds",
+		Help:    "Duration of use case calls by method and outcome.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"usecase", "method", "outcome"})
+)
+
+// Метрики журнала аудита. Запись в журнал не отменяет изменение
+// пользователя, поэтому потерянные записи видны только здесь
+var (
+	AuditWriteFailures = newCounterVec(prometheus.CounterOpts{
+		Name: "audit_write_failures_total",
+		Help: "Number of audit entries that failed to be written, by operation.",
+	}, []string{"operation"})
+)
+
+// HTTPDurationBuckets границы бакетов http_request_duration_seconds.
+// На них опираются цели по задержке в SLO
+var HTTPDurationBuckets = prometheus.DefBuckets
+
+// Метрики HTTP запросов. route — шаблон маршрута chi
+var (
+	HTTPRequests = newCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Number of HTTP requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = newHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Duration of HTTP requests by method, route and status.",
+		Buckets: HTTPDurationBuckets,
+	}, []string{"method", "route", "status"})
+)
+
+// Метрики исходящих HTTP запросов. client — имя интеграции,
+// status — код ответа или error, если ответа нет
+var (
+	HTTPClientRequestDuration = newHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_client_request_duration_seconds",
+		Help:    "Duration of outbound HTTP requests by client, method and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"client", "method", "status"})
+)
+
+// Метрики запросов к БД. operation — первое ключевое слово запроса
+var (
+	DBQueryDuration = newHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_query_duration_seconds",
+		Help:    "Duration of database queries by operation and outcome.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "outcome"})
+)
@@ -0,0 +1,159 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/config"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/push"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// JobMetrics метрики короткоживущей задачи (миграции, импорт, очистка),
+// которая завершается раньше, чем Prometheus успеет ее опросить. Метрики
+// регистрируются в собственном реестре через Factory и отправляются Push
+// по завершении задачи
+type JobMetrics struct {
+	Factory promauto.Factory
+
+	job      string
+	instance string
+	registry *prometheus.Registry
+	cfg      config.MetricsPush
+	client   *http.Client
+}
+
+func NewJobMetrics(job string, cfg config.MetricsPush) *JobMetrics {
+	registry := prometheus.NewRegistry()
+
+	instance, err := os.Hostname()
+	if err != nil {
+		instance = "unknown"
+	}
+
+	return &JobMetrics{
+		Factory:  promauto.With(registry),
+		job:      job,
+		instance: instance,
+		registry: registry,
+		cfg:      cfg,
+		client:   &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// Registry реестр задачи, например для стандартных коллекторов
+func (j *JobMetrics) Registry() *prometheus.Registry {
+	return j.registry
+}
+
+// Push отправляет метрики в Pushgateway и по remote_write, если адреса
+// заданы. Каждая отправка повторяется при временных ошибках
+func (j *JobMetrics) Push(ctx context.Context) error {
+	var errs []error
+
+	if j.cfg.PushgatewayURL != "" {
+		pusher := push.New(j.cfg.PushgatewayURL, j.job).
+			Gatherer(withoutLabels{j.registry, j.grouping()}).
+			Grouping("instance", j.instance).
+			Client(j.client)
+
+		if err := retry(ctx, j.cfg, func() error { return pusher.PushContext(ctx) }); err != nil {
+			errs = append(errs, fmt.Errorf("pushgateway: %w", err))
+		}
+	}
+
+	if j.cfg.RemoteWriteURL != "" {
+		if err := j.remoteWrite(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("remote write: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (j *JobMetrics) remoteWrite(ctx context.Context) error {
+	families, err := j.registry.Gather()
+	if err != nil {
+		return err
+	}
+
+	body, err := encodeWriteRequest(families, j.grouping(), time.Now())
+	if err != nil {
+		return err
+	}
+
+	return retry(ctx, j.cfg, func() error {
+		return sendRemoteWrite(ctx, j.client, j.cfg.RemoteWriteURL, body)
+	})
+}
+
+// grouping метки группировки, которые получают все ряды задачи
+func (j *JobMetrics) grouping() map[string]string {
+	return map[string]string{"job": j.job, "instance": j.instance}
+}
+
+// withoutLabels убирает из метрик метки группировки: клиент Pushgateway
+// отклоняет такие метрики, а remote write заменяет их значения
+type withoutLabels struct {
+	prometheus.Gatherer
+	names map[string]string
+}
+
+func (g withoutLabels) Gather() ([]*dto.MetricFamily, error) {
+	families, err := g.Gatherer.Gather()
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			pairs := m.Label[:0]
+			for _, p := range m.GetLabel() {
+				if _, ok := g.names[p.GetName()]; !ok {
+					pairs = append(pairs, p)
+				}
+			}
+			m.Label = pairs
+		}
+	}
+	return families, err
+}
+
+// permanentError ошибка, которую бессмысленно повторять
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// retry повторяет fn с экспоненциальной задержкой, пока не исчерпаны
+// попытки, ошибка не стала постоянной или не отменен ctx
+func retry(ctx context.Context, cfg config.MetricsPush, fn func() error) error {
+	backoff := cfg.RetryBackoff
+
+	var err error
+	for attempt := 0; attempt <= cfg.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return errors.Join(err, ctx.Err())
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		if err = fn(); err == nil {
+			return nil
+		}
+
+		var permanent permanentError
+		if errors.As(err, &permanent) {
+			return err
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,262 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/config"
+	"github.com/klauspost/compress/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// testJob задача с метриками всех типов. У счетчика есть собственная
+// метка job, которую должна заменить метка группировки
+func testJob(cfg config.MetricsPush) *JobMetrics {
+	j := NewJobMetrics("purge", cfg)
+
+	j.Factory.NewCounterVec(prometheus.CounterOpts{Name: "purged_rows_total", Help: "Purged rows."}, []string{"table", "job"}).
+		WithLabelValues("idempotency_keys", "stale").Add(3)
+	j.Factory.NewGauge(prometheus.GaugeOpts{Name: "purge_last_success_timestamp_seconds", Help: "Last success."}).Set(1700000000)
+	j.Factory.NewHistogram(prometheus.HistogramOpts{Name: "purge_duration_seconds", Help: "Duration.", Buckets: []float64{0.5, 1}}).Observe(0.7)
+
+	return j
+}
+
+func TestPushRemoteWrite(t *testing.T) {
+	var (
+		header http.Header
+		body   []byte
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	j := testJob(config.MetricsPush{RemoteWriteURL: srv.URL, Timeout: time.Second})
+	if err := j.Push(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if header.Get("Content-Encoding") != "snappy" || header.Get("Content-Type") != "application/x-protobuf" {
+		t.Fatalf("unexpected headers %v", header)
+	}
+
+	raw, err := snappy.Decode(nil, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := decodeWriteRequest(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	instance := `instance="` + j.instance + `"`
+	want := map[string]float64{
+		`purged_rows_total{` + instance + `,job="purge",table="idempotency_keys"}`: 3,
+		`purge_last_success_timestamp_seconds{` + instance + `,job="purge"}`:       1700000000,
+		`purge_duration_seconds_bucket{` + instance + `,job="purge",le="0.5"}`:     0,
+		`purge_duration_seconds_bucket{` + instance + `,job="purge",le="1"}`:       1,
+		`purge_duration_seconds_bucket{` + instance + `,job="purge",le="+Inf"}`:    1,
+		`purge_duration_seconds_sum{` + instance + `,job="purge"}`:                 0.7,
+		`purge_duration_seconds_count{` + instance + `,job="purge"}`:               1,
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d series, want %d: %v", len(got), len(want), got)
+	}
+	for key, value := range want {
+		if v, ok := got[key]; !ok || v != value {
+			t.Errorf("series %s = %v (present %v), want %v", key, v, ok, value)
+		}
+	}
+}
+
+func TestPushGateway(t *testing.T) {
+	var (
+		method, path string
+		families     map[string]*dto.MetricFamily
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+
+		families = make(map[string]*dto.MetricFamily)
+		dec := expfmt.NewDecoder(r.Body, expfmt.ResponseFormat(r.Header))
+		for {
+			var mf dto.MetricFamily
+			if err := dec.Decode(&mf); err != nil {
+				break
+			}
+			families[mf.GetName()] = &mf
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	j := testJob(config.MetricsPush{PushgatewayURL: srv.URL, Timeout: time.Second})
+	if err := j.Push(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if method != http.MethodPut || path != "/metrics/job/purge/instance/"+j.instance {
+		t.Fatalf("request %s %s, want PUT to the job and instance group", method, path)
+	}
+
+	rows, ok := families["purged_rows_total"]
+	if !ok || rows.GetMetric()[0].GetCounter().GetValue() != 3 {
+		t.Fatalf("purged_rows_total not pushed: %v", families)
+	}
+	for _, p := range rows.GetMetric()[0].GetLabel() {
+		if p.GetName() == "job" {
+			t.Fatalf("purged_rows_total pushed with its own job label %q", p.GetValue())
+		}
+	}
+	if _, ok := families["purge_duration_seconds"]; !ok {
+		t.Fatalf("purge_duration_seconds not pushed: %v", families)
+	}
+}
+
+func TestPushRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []int
+		wantCalls int32
+		wantErr   bool
+	}{
+		{name: "recovers after 503", statuses: []int{503, 204}, wantCalls: 2},
+		{name: "retries 429", statuses: []int{429, 429, 204}, wantCalls: 3},
+		{name: "gives up after retries", statuses: []int{500, 500, 500}, wantCalls: 3, wantErr: true},
+		{name: "400 is not retried", statuses: []int{400, 204}, wantCalls: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := calls.Add(1)
+				w.WriteHeader(tt.statuses[min(int(n), len(tt.statuses))-1])
+			}))
+			defer srv.Close()
+
+			j := testJob(config.MetricsPush{RemoteWriteURL: srv.URL, Timeout: time.Second, Retries: 2, RetryBackoff: time.Millisecond})
+			err := j.Push(context.Background())
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Push() = %v, want error %v", err, tt.wantErr)
+			}
+			if calls.Load() != tt.wantCalls {
+				t.Fatalf("server got %d requests, want %d", calls.Load(), tt.wantCalls)
+			}
+		})
+	}
+}
+
+// decodeWriteRequest разбирает WriteRequest по схеме prompb в ряды вида
+// name{label="value",...}. Повтор метки или нарушение порядка меток
+// считается ошибкой, как в приемниках remote write
+func decodeWriteRequest(b []byte) (map[string]float64, error) {
+	out := make(map[string]float64)
+
+	err := consumeFields(b, func(num protowire.Number, v []byte) error {
+		if num != fieldWriteRequestTimeseries {
+			return fmt.Errorf("unexpected WriteRequest field %d", num)
+		}
+
+		var (
+			labels []label
+			value  float64
+		)
+		err := consumeFields(v, func(num protowire.Number, v []byte) error {
+			switch num {
+			case fieldTimeSeriesLabels:
+				var l label
+				err := consumeFields(v, func(num protowire.Number, v []byte) error {
+					if num == fieldLabelName {
+						l.name = string(v)
+					} else {
+						l.value = string(v)
+					}
+					return nil
+				})
+				labels = append(labels, l)
+				return err
+			case fieldTimeSeriesSamples:
+				return consumeFields(v, func(num protowire.Number, v []byte) error {
+					if num == fieldSampleValue {
+						bits, _ := protowire.ConsumeFixed64(v)
+						value = math.Float64frombits(bits)
+					}
+					return nil
+				})
+			}
+			return fmt.Errorf("unexpected TimeSeries field %d", num)
+		})
+		if err != nil {
+			return err
+		}
+
+		if !sort.SliceIsSorted(labels, func(i, j int) bool { return labels[i].name < labels[j].name }) {
+			return fmt.Errorf("labels are not sorted: %v", labels)
+		}
+
+		var name string
+		var pairs []string
+		for i, l := range labels {
+			if i > 0 && labels[i-1].name == l.name {
+				return fmt.Errorf("duplicate label %s", l.name)
+			}
+			if l.name == "__name__" {
+				name = l.value
+				continue
+			}
+			pairs = append(pairs, fmt.Sprintf("%s=%q", l.name, l.value))
+		}
+		out[name+"{"+strings.Join(pairs, ",")+"}"] = value
+		return nil
+	})
+
+	return out, err
+}
+
+// consumeFields вызывает fn для каждого поля сообщения с типом bytes
+// или fixed64 (значение передается как есть)
+func consumeFields(b []byte, fn func(protowire.Number, []byte) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		var v []byte
+		switch typ {
+		case protowire.BytesType:
+			v, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			v = b[:max(n, 0)]
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		if err := fn(num, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"database/sql"
+	"regexp"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// registry регистрирует метрики в реестре по умолчанию и запоминает
+// коллекторы, чтобы по определениям метрик можно было проверять запросы
+// дашбордов и правил
+type registry struct {
+	prometheus.Registerer
+
+	mu         sync.Mutex
+	collectors []prometheus.Collector
+}
+
+func (r *registry) Register(c prometheus.Collector) error {
+	if err := r.Registerer.Register(c); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.collectors = append(r.collectors, c)
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *registry) MustRegister(cs ...prometheus.Collector) {
+	for _, c := range cs {
+		if err := r.Register(c); err != nil {
+			panic(err)
+		}
+	}
+}
+
+var (
+	defaultRegistry = &registry{Registerer: prometheus.DefaultRegisterer}
+	factory         = promauto.With(defaultRegistry)
+)
+
+// RegisterDBStats публикует состояние пула соединений (go_sql_*)
+func RegisterDBStats(db *sql.DB, name string) error {
+	return defaultRegistry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// fqNamePattern Desc не отдает имя метрики напрямую, только в String()
+var fqNamePattern = regexp.MustCompile(`fqName: "([^"]+)"`)
+
+// Names имена всех метрик сервиса: объявленных в пакете, стандартных
+// метрик Go и процесса, а также пула соединений, который регистрируется
+// только после подключения к БД
+func Names() map[string]struct{} {
+	defaultRegistry.mu.Lock()
+	cs := append([]prometheus.Collector{
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		collectors.NewDBStatsCollector(nil, ""),
+	}, defaultRegistry.collectors...)
+	defaultRegistry.mu.Unlock()
+
+	descs := make(chan *prometheus.Desc)
+	go func() {
+		for _, c := range cs {
+			c.Describe(descs)
+		}
+		close(descs)
+	}()
+
+	names := make(map[string]struct{})
+	for desc := range descs {
+		if m := fqNamePattern.FindStringSubmatch(desc.String()); m != nil {
+			names[m[1]] = struct{}{}
+		}
+	}
+	return names
+}
@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TestDescName Names разбирает Desc.String(): тест ловит изменение его
+// формата при обновлении client_golang
+func TestDescName(t *testing.T) {
+	desc := prometheus.NewDesc("test_requests_total", "Test.", []string{"route"}, prometheus.Labels{"service": "api"})
+
+	m := fqNamePattern.FindStringSubmatch(desc.String())
+	if m == nil || m[1] != "test_requests_total" {
+		t.Fatalf("fqName not found in %q", desc.String())
+	}
+}
+
+func TestNames(t *testing.T) {
+	names := Names()
+
+	for _, name := range []string{
+		// объявленные в пакете
+		"http_requests_total",
+		"http_request_duration_seconds",
+		"http_error_responses_total",
+		"panics_total",
+		// стандартные и пул соединений
+		"go_goroutines",
+		"process_cpu_seconds_total",
+		"go_sql_open_connections",
+	} {
+		if _, ok := names[name]; !ok {
+			t.Errorf("Names() has no %s", name)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Номера полей protobuf из prometheus/prompb (remote write 1.0)
+const (
+	fieldWriteRequestTimeseries = 1
+	fieldTimeSeriesLabels       = 1
+	fieldTimeSeriesSamples      = 2
+	fieldLabelName              = 1
+	fieldLabelValue             = 2
+	fieldSampleValue            = 1
+	fieldSampleTimestamp        = 2
+)
+
+type label struct {
+	name, value string
+}
+
+type series struct {
+	labels []label
+	value  float64
+}
+
+// sendRemoteWrite ответы 4xx, кроме 429, не повторяются: повтор
+// того же запроса получит тот же ответ
+func sendRemoteWrite(ctx context.Context, client *http.Client, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return permanentError{err}
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	if resp.StatusCode/100 == 4 && resp.StatusCode != http.StatusTooManyRequests {
+		return permanentError{err}
+	}
+	return err
+}
+
+// encodeWriteRequest сжатый snappy WriteRequest со всеми рядами семейств.
+// Гистограммы и summary раскладываются на ряды так же, как при опросе
+func encodeWriteRequest(families []*dto.MetricFamily, extra map[string]string, now time.Time) ([]byte, error) {
+	ts := now.UnixMilli()
+
+	var buf []byte
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			for _, s := range flatten(family, m) {
+				s.labels = withLabels(s.labels, m.GetLabel(), extra)
+				buf = protowire.AppendTag(buf, fieldWriteRequestTimeseries, protowire.BytesType)
+				buf = protowire.AppendBytes(buf, encodeSeries(s, ts))
+			}
+		}
+	}
+
+	return snappy.Encode(nil, buf), nil
+}
+
+func flatten(family *dto.MetricFamily, m *dto.Metric) []series {
+	name := family.GetName()
+
+	switch family.GetType() {
+	case dto.MetricType_COUNTER:
+		return []series{named(name, m.GetCounter().GetValue())}
+	case dto.MetricType_GAUGE:
+		return []series{named(name, m.GetGauge().GetValue())}
+	case dto.MetricType_UNTYPED:
+		return []series{named(name, m.GetUntyped().GetValue())}
+	case dto.MetricType_HISTOGRAM:
+		h := m.GetHistogram()
+		out := make([]series, 0, len(h.GetBucket())+3)
+		for _, b := range h.GetBucket() {
+			s := named(name+"_bucket", float64(b.GetCumulativeCount()))
+			s.labels = append(s.labels, label{"le", formatFloat(b.GetUpperBound())})
+			out = append(out, s)
+		}
+		inf := named(name+"_bucket", float64(h.GetSampleCount()))
+		inf.labels = append(inf.labels, label{"le", "+Inf"})
+		return append(out, inf,
+			named(name+"_sum", h.GetSampleSum()),
+			named(name+"_count", float64(h.GetSampleCount())),
+		)
+	case dto.MetricType_SUMMARY:
+		sm := m.GetSummary()
+		out := make([]series, 0, len(sm.GetQuantile())+2)
+		for _, q := range sm.GetQuantile() {
+			s := named(name, q.GetValue())
+			s.labels = append(s.labels, label{"quantile", formatFloat(q.GetQuantile())})
+			out = append(out, s)
+		}
+		return append(out,
+			named(name+"_sum", sm.GetSampleSum()),
+			named(name+"_count", float64(sm.GetSampleCount())),
+		)
+	default:
+		return nil
+	}
+}
+
+func named(name string, value float64) series {
+	return series{labels: []label{{"__name__", name}}, value: value}
+}
+
+// withLabels метки ряда должны быть отсортированы по имени и не должны
+// повторяться: extra заменяют одноименные метки метрики, как Pushgateway
+// заменяет их метками группировки
+func withLabels(labels []label, pairs []*dto.LabelPair, extra map[string]string) []label {
+	for _, p := range pairs {
+		if _, ok := extra[p.GetName()]; ok {
+			continue
+		}
+		labels = append(labels, label{p.GetName(), p.GetValue()})
+	}
+	for name, value := range extra {
+		labels = append(labels, label{name, value})
+	}
+
+	sort.Slice(labels, func(i, j int) bool { return labels[i].name < labels[j].name })
+	return labels
+}
+
+func encodeSeries(s series, ts int64) []byte {
+	var buf []byte
+	for _, l := range s.labels {
+		var lb []byte
+		lb = protowire.AppendTag(lb, fieldLabelName, protowire.BytesType)
+		lb = protowire.AppendString(lb, l.name)
+		lb = protowire.AppendTag(lb, fieldLabelValue, protowire.BytesType)
+		lb = protowire.AppendString(lb, l.value)
+
+		buf = protowire.AppendTag(buf, fieldTimeSeriesLabels, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, lb)
+	}
+
+	var sb []byte
+	sb = protowire.AppendTag(sb, fieldSampleValue, protowire.Fixed64Type)
+	sb = protowire.AppendFixed64(sb, math.Float64bits(s.value))
+	sb = protowire.AppendTag(sb, fieldSampleTimestamp, protowire.VarintType)
+	sb = protowire.AppendVarint(sb, uint64(ts))
+
+	buf = protowire.AppendTag(buf, fieldTimeSeriesSamples, protowire.BytesType)
+	return protowire.AppendBytes(buf, sb)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,278 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/config"
+)
+
+// Диалекты StatsD
+const (
+	FlavorStatsD    = "statsd"
+	FlavorDogStatsD = "dogstatsd"
+)
+
+// StatsD клиент StatsD/DogStatsD поверх UDP. Строки копятся в буфере
+// и отправляются пакетами не больше MaxPacketSize раз в FlushInterval.
+// Ошибки отправки не возвращаются: потеря метрик по UDP допустима
+type StatsD struct {
+	conn      net.Conn
+	prefix    string
+	dogstatsd bool
+	tags      string
+	maxPacket int
+
+	mu  sync.Mutex
+	buf []byte
+
+	closeOnce sync.Once
+	done      chan struct{}
+	stopped   chan struct{}
+}
+
+func NewStatsD(cfg config.StatsD) (*StatsD, error) {
+	var dogstatsd bool
+	switch cfg.Flavor {
+	case FlavorDogStatsD:
+		dogstatsd = true
+	case FlavorStatsD:
+	default:
+		return nil, fmt.Errorf("unknown statsd flavor %q", cfg.Flavor)
+	}
+
+	conn, err := net.Dial("udp", cfg.Address)
+	if err != nil {
+		return nil, fmt.Errorf("statsd: %w", err)
+	}
+
+	s := &StatsD{
+		conn:      conn,
+		prefix:    cfg.Prefix,
+		dogstatsd: dogstatsd,
+		tags:      globalTags(cfg.Tags),
+		maxPacket: max(cfg.MaxPacketSize, 512),
+		done:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+	interval := cfg.FlushInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	go s.run(interval)
+
+	return s, nil
+}
+
+// Close отправляет накопленные строки и закрывает соединение. Если ctx
+// истек раньше отправки, соединение все равно закрывается. Повторный
+// вызов безопасен
+func (s *StatsD) Close(ctx context.Context) error {
+	s.closeOnce.Do(func() { close(s.done) })
+
+	var ctxErr error
+	select {
+	case <-s.stopped:
+	case <-ctx.Done():
+		ctxErr = ctx.Err()
+	}
+
+	err := s.conn.Close()
+	if errors.Is(err, net.ErrClosed) {
+		err = nil
+	}
+	return errors.Join(ctxErr, err)
+}
+
+func (s *StatsD) run(interval time.Duration) {
+	defer close(s.stopped)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			s.mu.Lock()
+			s.flush()
+			s.mu.Unlock()
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			s.flush()
+			s.mu.Unlock()
+		}
+	}
+}
+
+// write добавляет строку в пакет. Переполненный пакет отправляется сразу
+func (s *StatsD) write(line string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.buf) > 0 && len(s.buf)+1+len(line) > s.maxPacket {
+		s.flush()
+	}
+	if len(s.buf) > 0 {
+		s.buf = append(s.buf, '\n')
+	}
+	s.buf = append(s.buf, line...)
+}
+
+// flush вызывается под s.mu
+func (s *StatsD) flush() {
+	if len(s.buf) == 0 {
+		return
+	}
+	_, _ = s.conn.Write(s.buf)
+	s.buf = s.buf[:0]
+}
+
+// line строит строку протокола. В DogStatsD метки передаются тегами,
+// в StatsD значения меток добавляются к имени через точку
+func (s *StatsD) line(name string, labels, values []string, value, typ string) string {
+	var b strings.Builder
+	b.WriteString(s.prefix)
+	b.WriteString(sanitizeName(name))
+
+	if !s.dogstatsd {
+		for _, v := range values {
+			b.WriteByte('.')
+			b.WriteString(sanitizeName(v))
+		}
+	}
+
+	b.WriteByte(':')
+	b.WriteString(value)
+	b.WriteByte('|')
+	b.WriteString(typ)
+
+	if s.dogstatsd {
+		tags := s.tags
+		for i, l := range labels {
+			if i >= len(values) {
+				break
+			}
+			if tags != "" {
+				tags += ","
+			}
+			tags += l + ":" + sanitizeTag(values[i])
+		}
+		if tags != "" {
+			b.WriteString("|#")
+			b.WriteString(tags)
+		}
+	}
+
+	return b.String()
+}
+
+func (s *StatsD) counter(name string, labels, values []string) Counter {
+	return statsdCounter{s: s, name: name, labels: labels, values: values}
+}
+
+func (s *StatsD) gauge(name string, labels, values []string) Gauge {
+	return statsdGauge{s: s, name: name, labels: labels, values: values}
+}
+
+func (s *StatsD) observer(name string, labels, values []string) Observer {
+	return statsdObserver{s: s, name: name, labels: labels, values: values}
+}
+
+type statsdCounter struct {
+	s              *StatsD
+	name           string
+	labels, values []string
+}
+
+func (c statsdCounter) Inc() { c.Add(1) }
+
+func (c statsdCounter) Add(v float64) {
+	c.s.write(c.s.line(c.name, c.labels, c.values, formatFloat(v), "c"))
+}
+
+type statsdGauge struct {
+	s              *StatsD
+	name           string
+	labels, values []string
+}
+
+func (g statsdGauge) Set(v float64) {
+	// отрицательное значение со знаком протокол считает изменением
+	if v < 0 {
+		g.s.write(g.s.line(g.name, g.labels, g.values, "0", "g"))
+	}
+	g.s.write(g.s.line(g.name, g.labels, g.values, formatFloat(v), "g"))
+}
+
+func (g statsdGauge) Inc() { g.Add(1) }
+func (g statsdGauge) Dec() { g.Add(-1) }
+
+func (g statsdGauge) Add(v float64) {
+	value := formatFloat(v)
+	if v >= 0 {
+		value = "+" + value
+	}
+	g.s.write(g.s.line(g.name, g.labels, g.values, value, "g"))
+}
+
+type statsdObserver struct {
+	s              *StatsD
+	name           string
+	labels, values []string
+}
+
+// Observe в StatsD нет гистограмм: значение отправляется таймером,
+// длительности в секундах переводятся в миллисекунды
+func (o statsdObserver) Observe(v float64) {
+	if o.s.dogstatsd {
+		o.s.write(o.s.line(o.name, o.labels, o.values, formatFloat(v), "h"))
+		return
+	}
+
+	if strings.HasSuffix(o.name, "_seconds") {
+		v *= 1000
+	}
+	o.s.write(o.s.line(o.name, o.labels, o.values, strconv.FormatFloat(v, 'f', -1, 64), "ms"))
+}
+
+func globalTags(tags map[string]string) string {
+	pairs := make([]string, 0, len(tags))
+	for k, v := range tags {
+		pairs = append(pairs, k+":"+sanitizeTag(v))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// sanitizeName имя и значения меток в StatsD не могут содержать
+// разделители протокола и точки, кроме разделяющих сегменты
+func sanitizeName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, s)
+}
+
+// sanitizeTag значения тегов DogStatsD не могут содержать , | # и перевод строки
+func sanitizeTag(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ',', '|', '#', '\n':
+			return '_'
+		default:
+			return r
+		}
+	}, s)
+}
@@ -0,0 +1,180 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/config"
+)
+
+// newTestStatsD клиент, отправляющий пакеты на локальный UDP-порт.
+// Период отправки большой: пакеты уходят только при переполнении и в Close
+func newTestStatsD(t *testing.T, flavor string, maxPacket int) (*StatsD, net.PacketConn) {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	s, err := NewStatsD(config.StatsD{
+		Address:       conn.LocalAddr().String(),
+		Flavor:        flavor,
+		Prefix:        "app.",
+		Tags:          map[string]string{"env": "test"},
+		FlushInterval: time.Hour,
+		MaxPacketSize: maxPacket,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s, conn
+}
+
+// packets закрывает клиент и читает все отправленные им пакеты
+func packets(t *testing.T, s *StatsD, conn net.PacketConn) []string {
+	t.Helper()
+
+	if err := s.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var out []string
+	buf := make([]byte, 64*1024)
+	for {
+		_ = conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+		n, _, err := conn.ReadFrom(buf)
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			return out
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, string(buf[:n]))
+	}
+}
+
+func TestStatsDLineFormat(t *testing.T) {
+	tests := []struct {
+		flavor string
+		want   []string
+	}{
+		{
+			flavor: FlavorStatsD,
+			want: []string{
+				"app.http_requests_total.GET.200:2|c",
+				"app.queue_depth.a_b:+3|g",
+				"app.http_request_duration_seconds.GET.200:250|ms",
+			},
+		},
+		{
+			flavor: FlavorDogStatsD,
+			want: []string{
+				"app.http_requests_total:2|c|#env:test,method:GET,status:200",
+				"app.queue_depth:+3|g|#env:test,queue:a.b",
+				"app.http_request_duration_seconds:0.25|h|#env:test,method:GET,status:200",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.flavor, func(t *testing.T) {
+			s, conn := newTestStatsD(t, tt.flavor, 0)
+
+			labels, values := []string{"method", "status"}, []string{"GET", "200"}
+			s.counter("http_requests_total", labels, values).Add(2)
+			s.gauge("queue_depth", []string{"queue"}, []string{"a.b"}).Add(3)
+			s.observer("http_request_duration_seconds", labels, values).Observe(0.25)
+
+			got := packets(t, s, conn)
+			if len(got) != 1 || got[0] != strings.Join(tt.want, "\n") {
+				t.Fatalf("packets = %q, want %q", got, strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestStatsDSplitsPackets(t *testing.T) {
+	const maxPacket = 512
+	s, conn := newTestStatsD(t, FlavorStatsD, maxPacket)
+
+	c := s.counter("jobs_processed_total", nil, nil)
+	const lines = 100
+	for range lines {
+		c.Inc()
+	}
+
+	got := packets(t, s, conn)
+	if len(got) < 2 {
+		t.Fatalf("got %d packets, want the lines split into several", len(got))
+	}
+
+	var total int
+	for _, p := range got {
+		if len(p) > maxPacket {
+			t.Fatalf("packet of %d bytes exceeds %d", len(p), maxPacket)
+		}
+		for _, line := range strings.Split(p, "\n") {
+			if line != "app.jobs_processed_total:1|c" {
+				t.Fatalf("unexpected line %q", line)
+			}
+			total++
+		}
+	}
+	if total != lines {
+		t.Fatalf("got %d lines, want %d", total, lines)
+	}
+}
+
+// TestStatsDNegativeGauge значение со знаком минус протокол считает
+// изменением, поэтому перед ним gauge сбрасывается в 0
+func TestStatsDNegativeGauge(t *testing.T) {
+	s, conn := newTestStatsD(t, FlavorStatsD, 0)
+
+	s.gauge("temperature", nil, nil).Set(-5)
+
+	got := packets(t, s, conn)
+	want := "app.temperature:0|g\napp.temperature:-5|g"
+	if len(got) != 1 || got[0] != want {
+		t.Fatalf("packets = %q, want %q", got, want)
+	}
+}
+
+func TestStatsDCloseTwice(t *testing.T) {
+	s, _ := newTestStatsD(t, FlavorStatsD, 0)
+
+	if err := s.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(context.Background()); err != nil {
+		t.Fatalf("second Close() = %v", err)
+	}
+}
+
+// TestStatsDCloseExpiredContext соединение закрывается, даже если ctx
+// истек раньше остановки клиента
+func TestStatsDCloseExpiredContext(t *testing.T) {
+	s, _ := newTestStatsD(t, FlavorStatsD, 0)
+
+	// пока s.mu занят, фоновая горутина не может завершить отправку
+	s.mu.Lock()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := s.Close(ctx)
+	s.mu.Unlock()
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Close() = %v, want %v", err, context.Canceled)
+	}
+	if _, err := s.conn.Write([]byte("x:1|c")); !errors.Is(err, net.ErrClosed) {
+		t.Fatalf("write after Close() = %v, want connection closed", err)
+	}
+}
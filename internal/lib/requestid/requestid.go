@@ -0,0 +1,53 @@
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Header заголовок, в котором идентификатор запроса принимается
+// от клиента, возвращается ему и передается во внешние вызовы
+const Header = "X-Request-ID"
+
+// maxLength длинные идентификаторы не принимаются: они попадают в логи
+// и заголовки исходящих запросов
+const maxLength = 128
+
+// NewContext сохраняет идентификатор под ключом chi, поэтому он доступен
+// и через middleware.GetReqID
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, middleware.RequestIDKey, id)
+}
+
+// FromContext пустая строка, если идентификатора нет
+func FromContext(ctx context.Context) string {
+	return middleware.GetReqID(ctx)
+}
+
+// New случайный идентификатор из 32 шестнадцатеричных символов
+func New() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// Valid принимаются идентификаторы до 128 символов из букв, цифр и . _ - : / + =
+// Остальные отбрасываются, чтобы клиент не мог внедрить данные в логи
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '.', r == '_', r == '-', r == ':', r == '/', r == '+', r == '=':
+		default:
+			return false
+		}
+	}
+	return true
+}
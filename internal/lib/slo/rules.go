@@ -0,0 +1,180 @@
+package slo
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	kindAvailability = "availability"
+	kindLatency      = "latency"
+)
+
+// windows окна, по которым записывается доля ошибок
+var windows = []string{"5m", "30m", "1h", "2h", "6h", "1d", "3d"}
+
+// burnRate пара окон многооконного алерта: алерт срабатывает, если бюджет
+// ошибок расходуется быстрее Factor и в длинном, и в коротком окне.
+// Короткое окно снимает алерт вскоре после восстановления
+type burnRate struct {
+	Long     string
+	Short    string
+	Factor   float64
+	Severity string
+	For      string
+}
+
+// burnRates пороги из SRE Workbook для цели на 30 дней: page — 2% бюджета
+// за час и 5% за 6 часов, ticket — 10% за сутки и 10% за 3 дня
+var burnRates = []burnRate{
+	{Long: "1h", Short: "5m", Factor: 14.4, Severity: "page", For: "2m"},
+	{Long: "6h", Short: "30m", Factor: 6, Severity: "page", For: "15m"},
+	{Long: "1d", Short: "2h", Factor: 3, Severity: "ticket", For: "1h"},
+	{Long: "3d", Short: "6h", Factor: 1, Severity: "ticket", For: "3h"},
+}
+
+// RuleFile файл правил Prometheus
+type RuleFile struct {
+	Groups []RuleGroup `yaml:"groups"`
+}
+
+type RuleGroup struct {
+	Name  string `yaml:"name"`
+	Rules []Rule `yaml:"rules"`
+}
+
+// Rule правило записи (Record) или алерта (Alert)
+type Rule struct {
+	Record      string            `yaml:"record,omitempty"`
+	Alert       string            `yaml:"alert,omitempty"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// Rules строит правила записи и алерты для каждой цели: отдельная
+// группа на SLO, чтобы правила одной цели вычислялись согласованно
+func Rules(slos []SLO) (RuleFile, error) {
+	if err := Validate(slos); err != nil {
+		return RuleFile{}, err
+	}
+
+	var file RuleFile
+	for _, s := range slos {
+		group := RuleGroup{Name: "slo-" + s.Name}
+
+		if s.Availability > 0 {
+			group.Rules = append(group.Rules, objectiveRules(s, kindAvailability, s.Availability, availabilityErrors)...)
+		}
+		if s.LatencyTarget > 0 {
+			group.Rules = append(group.Rules, objectiveRules(s, kindLatency, s.LatencyTarget, latencyErrors)...)
+		}
+
+		file.Groups = append(file.Groups, group)
+	}
+
+	return file, nil
+}
+
+// Marshal YAML файла правил с заголовком о генерации
+func Marshal(file RuleFile) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("# Code generated by cmd/slogen; DO NOT EDIT.\n")
+
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(file); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func objectiveRules(s SLO, kind string, target float64, errorRatio func(SLO, string) string) []Rule {
+	labels := map[string]string{"slo": s.Name, "method": s.Method, "route": s.Route}
+
+	rules := make([]Rule, 0, len(windows)+len(burnRates))
+	for _, window := range windows {
+		rules = append(rules, Rule{
+			Record: recordName(kind, window),
+			Expr:   errorRatio(s, window),
+			Labels: labels,
+		})
+	}
+
+	budget := 1 - target
+	for _, br := range burnRates {
+		threshold := formatFloat(br.Factor * budget)
+		selector := fmt.Sprintf(`{slo=%q}`, s.Name)
+
+		rules = append(rules, Rule{
+			Alert: alertName(kind),
+			Expr: fmt.Sprintf("%s%s > %s\nand\n%s%s > %s",
+				recordName(kind, br.Long), selector, threshold,
+				recordName(kind, br.Short), selector, threshold),
+			For: br.For,
+			Labels: map[string]string{
+				"slo":      s.Name,
+				"severity": br.Severity,
+				"window":   br.Long,
+			},
+			Annotations: map[string]string{
+				"summary": fmt.Sprintf("%s %s SLO of %s %s is burning error budget %sx faster than allowed",
+					s.Name, kind, s.Method, s.Route, formatFloat(br.Factor)),
+				"description": fmt.Sprintf("Error ratio over %s and %s is above %s (target %s).",
+					br.Long, br.Short, threshold, formatFloat(target)),
+			},
+		})
+	}
+
+	return rules
+}
+
+// availabilityErrors доля ответов 5xx
+func availabilityErrors(s SLO, window string) string {
+	selector := routeSelector(s)
+	return fmt.Sprintf(`sum(rate(http_requests_total{%s,status=~"5.."}[%s]))
+/
+sum(rate(http_requests_total{%s}[%s]))`, selector, window, selector, window)
+}
+
+// latencyErrors доля запросов медленнее порога
+func latencyErrors(s SLO, window string) string {
+	selector := routeSelector(s)
+	return fmt.Sprintf(`1 - (
+  sum(rate(http_request_duration_seconds_bucket{%s,le="%s"}[%s]))
+  /
+  sum(rate(http_request_duration_seconds_count{%s}[%s]))
+)`, selector, formatFloat(s.LatencyThreshold), window, selector, window)
+}
+
+func routeSelector(s SLO) string {
+	return fmt.Sprintf(`method=%q,route=%q`, s.Method, s.Route)
+}
+
+func recordName(kind, window string) string {
+	return fmt.Sprintf("slo:%s_errors:ratio_rate%s", kind, window)
+}
+
+func alertName(kind string) string {
+	switch kind {
+	case kindLatency:
+		return "SLOLatencyBudgetBurn"
+	default:
+		return "SLOAvailabilityBudgetBurn"
+	}
+}
+
+// formatFloat округляет погрешность вычислений: 14.4*(1-0.999)
+// должно записаться как 0.0144
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1e9)/1e9, 'f', -1, 64)
+}
@@ -0,0 +1,77 @@
+package slo
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+func TestRulesGolden(t *testing.T) {
+	rules, err := Rules(Default)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Marshal(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	golden := filepath.Join("testdata", "default.yml")
+	if *update {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("rules differ from %s, run go test ./internal/lib/slo -update and review the diff", golden)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := SLO{Name: "users-get", Method: "GET", Route: "/api/v1/users/{id}", Availability: 0.999, LatencyTarget: 0.99, LatencyThreshold: 0.25}
+
+	tests := []struct {
+		name    string
+		slos    []SLO
+		wantErr string
+	}{
+		{name: "default", slos: Default},
+		{name: "duplicate name", slos: []SLO{valid, valid}, wantErr: "duplicate name"},
+		{name: "threshold is not a bucket", slos: []SLO{with(valid, func(s *SLO) { s.LatencyThreshold = 0.3 })}, wantErr: "not a histogram bucket"},
+		{name: "availability target is 1", slos: []SLO{with(valid, func(s *SLO) { s.Availability = 1 })}, wantErr: "targets must be in [0, 1)"},
+		{name: "latency target above 1", slos: []SLO{with(valid, func(s *SLO) { s.LatencyTarget = 1.5 })}, wantErr: "targets must be in [0, 1)"},
+		{name: "no objectives", slos: []SLO{with(valid, func(s *SLO) { s.Availability, s.LatencyTarget = 0, 0 })}, wantErr: "no objectives"},
+		{name: "missing route", slos: []SLO{with(valid, func(s *SLO) { s.Route = "" })}, wantErr: "required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.slos)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func with(s SLO, fn func(*SLO)) SLO {
+	fn(&s)
+	return s
+}
@@ -0,0 +1,67 @@
+package slo
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/metrics"
+)
+
+// SLO цели уровня обслуживания для маршрута chi. Нулевая цель не
+// генерирует правил. LatencyThreshold должен совпадать с границей бакета
+// http_request_duration_seconds; целые границы не используются, так как
+// в OpenMetrics метка le записывается как "1.0", а не "1"
+type SLO struct {
+	Name   string
+	Method string
+	Route  string
+
+	// Availability доля запросов без ответа 5xx
+	Availability float64
+	// LatencyTarget доля запросов быстрее LatencyThreshold секунд
+	LatencyTarget    float64
+	LatencyThreshold float64
+}
+
+// Default цели сервиса. Экспорт не имеет цели по задержке: ответ
+// передается потоком и его длительность зависит от объема выборки
+var Default = []SLO{
+	{Name: "users-get", Method: "GET", Route: "/api/v1/users/{id}", Availability: 0.999, LatencyTarget: 0.99, LatencyThreshold: 0.25},
+	{Name: "users-list", Method: "GET", Route: "/api/v1/users/", Availability: 0.999, LatencyTarget: 0.95, LatencyThreshold: 0.5},
+	{Name: "users-create", Method: "POST", Route: "/api/v1/users/", Availability: 0.999, LatencyTarget: 0.99, LatencyThreshold: 0.5},
+	{Name: "users-update", Method: "PUT", Route: "/api/v1/users/{id}", Availability: 0.999, LatencyTarget: 0.99, LatencyThreshold: 0.5},
+	{Name: "users-delete", Method: "DELETE", Route: "/api/v1/users/{id}", Availability: 0.999, LatencyTarget: 0.99, LatencyThreshold: 0.5},
+	{Name: "users-import", Method: "POST", Route: "/api/v1/users:bulk", Availability: 0.99, LatencyTarget: 0.95, LatencyThreshold: 2.5},
+	{Name: "users-export", Method: "GET", Route: "/api/v1/users:export", Availability: 0.99},
+}
+
+// Validate проверяет цели до генерации правил
+func Validate(slos []SLO) error {
+	names := make(map[string]struct{}, len(slos))
+
+	for _, s := range slos {
+		if s.Name == "" || s.Method == "" || s.Route == "" {
+			return fmt.Errorf("slo %q: name, method and route are required", s.Name)
+		}
+		if _, ok := names[s.Name]; ok {
+			return fmt.Errorf("slo %q: duplicate name", s.Name)
+		}
+		names[s.Name] = struct{}{}
+
+		if s.Availability == 0 && s.LatencyTarget == 0 {
+			return fmt.Errorf("slo %q: no objectives", s.Name)
+		}
+		if !validTarget(s.Availability) || !validTarget(s.LatencyTarget) {
+			return fmt.Errorf("slo %q: targets must be in [0, 1)", s.Name)
+		}
+		if s.LatencyTarget > 0 && !slices.Contains(metrics.HTTPDurationBuckets, s.LatencyThreshold) {
+			return fmt.Errorf("slo %q: latency threshold %v is not a histogram bucket", s.Name, s.LatencyThreshold)
+		}
+	}
+
+	return nil
+}
+
+func validTarget(target float64) bool {
+	return target >= 0 && target < 1
+}
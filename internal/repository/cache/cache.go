@@ -0,0 +1,15 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Cache хранилище закодированных значений с временем жизни.
+// Интерфейс рассчитан на внешние реализации (например, Redis),
+// поэтому значения передаются в сериализованном виде
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+}
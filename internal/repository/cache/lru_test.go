@@ -0,0 +1,33 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNewLRURejectsNonPositiveSize(t *testing.T) {
+	for _, size := range []int{0, -1} {
+		if _, err := NewLRU(size); err == nil {
+			t.Fatalf("NewLRU(%d) = nil error, want rejected", size)
+		}
+	}
+}
+
+func TestLRUEvictsOldest(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewLRU(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = c.Set(ctx, "a", []byte("1"), time.Minute)
+	_ = c.Set(ctx, "b", []byte("2"), time.Minute)
+
+	if _, ok, _ := c.Get(ctx, "a"); ok {
+		t.Fatal(`"a" is still cached, want it evicted`)
+	}
+	if v, ok, _ := c.Get(ctx, "b"); !ok || string(v) != "2" {
+		t.Fatalf(`Get("b") = %q, %v, want "2"`, v, ok)
+	}
+}
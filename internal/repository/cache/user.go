@@ -0,0 +1,178 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/config"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/logger/sl"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/metrics"
+	"golang.org/x/sync/singleflight"
+)
+
+const userCacheName = "user"
+
+// userEntry значение в кэше. Found=false означает, что пользователя нет в БД
+type userEntry struct {
+	Found   bool        `json:"found"`
+	Message string      `json:"message,omitempty"`
+	User    domain.User `json:"user"`
+}
+
+// userRepository декоратор над domain.UserRepository, кэширующий GetUserById.
+// Изменяющие операции сбрасывают запись в кэше
+type userRepository struct {
+	next  domain.UserRepository
+	cache Cache
+	cfg   config.Cache
+	group singleflight.Group
+	// generation увеличивается при каждой инвалидации. Загрузка, начатая
+	// до изменения, не сохраняет в кэш устаревшее значение
+	generation atomic.Uint64
+	log        *slog.Logger
+}
+
+func NewUserRepository(next domain.UserRepository, cache Cache, cfg config.Cache, log *slog.Logger) *userRepository {
+	return &userRepository{
+		next:  next,
+		cache: cache,
+		cfg:   cfg,
+		log:   log.With(slog.String("component", "repository/cache")),
+	}
+}
+
+func (r *userRepository) CreateUser(ctx context.Context, user domain.User) (domain.User, *domain.AppError) {
+	created, err := r.next.CreateUser(ctx, user)
+	if err != nil {
+		return created, err
+	}
+
+	// id мог быть закэширован как несуществующий
+	r.invalidate(ctx, created.ID)
+	return created, nil
+}
+
+func (r *userRepository) GetUserById(ctx context.Context, id uint) (domain.User, *domain.AppError) {
+	key := userKey(id)
+
+	if entry, ok := r.lookup(ctx, key); ok {
+		if !entry.Found {
+			metrics.CacheRequests.WithLabelValues(userCacheName, "negative_hit").Inc()
+			return domain.User{}, domain.NewNotFoundError(entry.Message)
+		}
+		metrics.CacheRequests.WithLabelValues(userCacheName, "hit").Inc()
+		return entry.User, nil
+	}
+
+	metrics.CacheRequests.WithLabelValues(userCacheName, "miss").Inc()
+
+	// Одновременные промахи по одному id выполняют один запрос к БД.
+	// Запрос не зависит от отмены контекста первого вызывающего
+	loadCtx := context.WithoutCancel(ctx)
+	ch := r.group.DoChan(key, func() (any, error) {
+		return r.load(loadCtx, key, id), nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return domain.User{}, domain.NewUnexpectedError(ctx.Err().Error())
+	case res := <-ch:
+		result := res.Val.(loadResult)
+		return result.user, result.err
+	}
+}
+
+func (r *userRepository) UpdateUser(ctx context.Context, user domain.User) (domain.User, *domain.AppError) {
+	updated, err := r.next.UpdateUser(ctx, user)
+	if err != nil {
+		return updated, err
+	}
+
+	r.invalidate(ctx, user.ID)
+	return updated, nil
+}
+
+func (r *userRepository) DeleteUserById(ctx context.Context, id uint) *domain.AppError {
+	if err := r.next.DeleteUserById(ctx, id); err != nil {
+		return err
+	}
+
+	r.invalidate(ctx, id)
+	return nil
+}
+
+type loadResult struct {
+	user domain.User
+	err  *domain.AppError
+}
+
+// load читает пользователя из БД и сохраняет результат в кэш.
+// Отсутствие пользователя кэшируется на NegativeTTL, прочие ошибки не кэшируются
+func (r *userRepository) load(ctx context.Context, key string, id uint) loadResult {
+	generation := r.generation.Load()
+	user, appErr := r.next.GetUserById(ctx, id)
+
+	switch {
+	case r.generation.Load() != generation:
+		// пользователь изменился во время чтения
+	case appErr == nil:
+		r.store(ctx, key, userEntry{Found: true, User: user}, r.cfg.TTL)
+	case appErr.Code == http.StatusNotFound && r.cfg.NegativeTTL > 0:
+		r.store(ctx, key, userEntry{Message: appErr.Message}, r.cfg.NegativeTTL)
+	}
+
+	return loadResult{user: user, err: appErr}
+}
+
+// lookup ошибки кэша не прерывают запрос: он уходит в БД
+func (r *userRepository) lookup(ctx context.Context, key string) (userEntry, bool) {
+	data, ok, err := r.cache.Get(ctx, key)
+	if err != nil {
+		r.log.WarnContext(ctx, "failed to read from cache", slog.String("key", key), sl.Err(err))
+		return userEntry{}, false
+	}
+	if !ok {
+		return userEntry{}, false
+	}
+
+	var entry userEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		r.log.WarnContext(ctx, "failed to decode cache entry", slog.String("key", key), sl.Err(err))
+		return userEntry{}, false
+	}
+
+	return entry, true
+}
+
+func (r *userRepository) store(ctx context.Context, key string, entry userEntry, ttl time.Duration) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		r.log.WarnContext(ctx, "failed to encode cache entry", slog.String("key", key), sl.Err(err))
+		return
+	}
+
+	if err := r.cache.Set(ctx, key, data, ttl); err != nil {
+		r.log.WarnContext(ctx, "failed to write to cache", slog.String("key", key), sl.Err(err))
+	}
+}
+
+func (r *userRepository) invalidate(ctx context.Context, id uint) {
+	key := userKey(id)
+	r.generation.Add(1)
+	// новые вызывающие не должны присоединяться к загрузке, начатой до изменения
+	r.group.Forget(key)
+
+	if err := r.cache.Delete(ctx, key); err != nil {
+		r.log.ErrorContext(ctx, "failed to invalidate cache entry", slog.String("key", key), sl.Err(err))
+	}
+}
+
+func userKey(id uint) string {
+	return "user:" + strconv.FormatUint(uint64(id), 10)
+}
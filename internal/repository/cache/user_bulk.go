@@ -0,0 +1,31 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+)
+
+// userBulkRepository декоратор над domain.UserBulkRepository. Пользователи,
+// сохраненные пакетом, сбрасываются в кэше userRepository так же, как
+// созданные по одному: их id могли быть закэшированы как несуществующие
+type userBulkRepository struct {
+	domain.UserBulkRepository
+	users *userRepository
+}
+
+func NewUserBulkRepository(next domain.UserBulkRepository, users *userRepository) *userBulkRepository {
+	return &userBulkRepository{UserBulkRepository: next, users: users}
+}
+
+func (r *userBulkRepository) CopyUsers(ctx context.Context, users []domain.User) ([]domain.User, *domain.AppError) {
+	created, err := r.UserBulkRepository.CopyUsers(ctx, users)
+	if err != nil {
+		return created, err
+	}
+
+	for _, user := range created {
+		r.users.invalidate(ctx, user.ID)
+	}
+	return created, nil
+}
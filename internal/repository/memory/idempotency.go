@@ -0,0 +1,65 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+)
+
+type idempotencyRepository struct {
+	mu      sync.Mutex
+	records map[string]domain.IdempotencyRecord
+}
+
+func NewIdempotencyRepository() *idempotencyRepository {
+	return &idempotencyRepository{records: make(map[string]domain.IdempotencyRecord)}
+}
+
+func (r *idempotencyRepository) Reserve(_ context.Context, record domain.IdempotencyRecord) (domain.IdempotencyRecord, bool, *domain.AppError) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.records[record.Key]; ok && existing.ExpiresAt.After(record.CreatedAt) {
+		return existing, false, nil
+	}
+
+	r.records[record.Key] = record
+	return record, true, nil
+}
+
+func (r *idempotencyRepository) Complete(_ context.Context, record domain.IdempotencyRecord) *domain.AppError {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.records[record.Key]; !ok || existing.Token != record.Token {
+		return domain.NewNotFoundError("idempotency key reservation has expired")
+	}
+	r.records[record.Key] = record
+	return nil
+}
+
+func (r *idempotencyRepository) Delete(_ context.Context, key, token string) *domain.AppError {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.records[key]; ok && existing.Token == token {
+		delete(r.records, key)
+	}
+	return nil
+}
+
+func (r *idempotencyRepository) DeleteExpired(_ context.Context, now time.Time) (int64, *domain.AppError) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var n int64
+	for key, record := range r.records {
+		if !record.ExpiresAt.After(now) {
+			delete(r.records, key)
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,47 @@
+package memory
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+)
+
+// TestIdempotencyExpiredReservation запрос, резерв которого истек и был
+// занят другим запросом, не перезаписывает и не освобождает чужой ключ
+func TestIdempotencyExpiredReservation(t *testing.T) {
+	ctx := context.Background()
+	repo := NewIdempotencyRepository()
+	now := time.Unix(1700000000, 0)
+
+	first := domain.IdempotencyRecord{Key: "k", Token: "first", CreatedAt: now, ExpiresAt: now.Add(time.Second)}
+	if _, reserved, err := repo.Reserve(ctx, first); err != nil || !reserved {
+		t.Fatalf("first Reserve() = %v, %v, want reserved", reserved, err)
+	}
+
+	later := now.Add(time.Minute)
+	second := domain.IdempotencyRecord{Key: "k", Token: "second", CreatedAt: later, ExpiresAt: later.Add(time.Second)}
+	if _, reserved, err := repo.Reserve(ctx, second); err != nil || !reserved {
+		t.Fatalf("second Reserve() = %v, %v, want the expired key reserved again", reserved, err)
+	}
+
+	first.StatusCode = http.StatusCreated
+	if err := repo.Complete(ctx, first); err == nil || err.Code != http.StatusNotFound {
+		t.Fatalf("Complete() with a stale token = %v, want not found", err)
+	}
+	if err := repo.Delete(ctx, first.Key, first.Token); err != nil {
+		t.Fatal(err.Message)
+	}
+
+	existing, reserved, err := repo.Reserve(ctx, domain.IdempotencyRecord{Key: "k", Token: "third", CreatedAt: later})
+	if err != nil || reserved || existing.Token != second.Token || existing.Completed() {
+		t.Fatalf("Reserve() = %+v, %v, %v, want the pending second reservation", existing, reserved, err)
+	}
+
+	second.StatusCode = http.StatusCreated
+	if err := repo.Complete(ctx, second); err != nil {
+		t.Fatalf("Complete() = %v", err.Message)
+	}
+}
@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+	"github.com/jmoiron/sqlx"
+)
+
+type apiKeyRepository struct {
+	db *sqlx.DB
+}
+
+func NewAPIKeyRepository(db *sqlx.DB) *apiKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+type apiKeyRow struct {
+	ID        uint         `db:"id"`
+	Name      string       `db:"name"`
+	Hash      string       `db:"hash"`
+	Roles     string       `db:"roles"`
+	UserID    uint         `db:"user_id"`
+	CreatedAt time.Time    `db:"created_at"`
+	RevokedAt sql.NullTime `db:"revoked_at"`
+}
+
+func (r apiKeyRow) toDomain() domain.APIKey {
+	key := domain.APIKey{
+		ID:        r.ID,
+		Name:      r.Name,
+		Hash:      r.Hash,
+		UserID:    r.UserID,
+		CreatedAt: r.CreatedAt,
+	}
+
+	for _, role := range strings.Split(r.Roles, ",") {
+		if role != "" {
+			key.Roles = append(key.Roles, role)
+		}
+	}
+
+	if r.RevokedAt.Valid {
+		key.RevokedAt = &r.RevokedAt.Time
+	}
+
+	return key
+}
+
+func (r *apiKeyRepository) GetAPIKeyByHash(hash string) (domain.APIKey, *domain.AppError) {
+	var row apiKeyRow
+
+	err := r.db.Get(&row, `SELECT id, name, hash, roles, user_id, created_at, revoked_at
+		FROM api_keys WHERE hash = $1`, hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.APIKey{}, domain.NewNotFoundError("API key not found")
+	}
+
+	if err != nil {
+		return domain.APIKey{}, domain.NewUnexpectedError(err.Error())
+	}
+
+	return row.toDomain(), nil
+}
@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+	"github.com/jmoiron/sqlx"
+)
+
+type auditRepository struct {
+	db *sqlx.DB
+}
+
+func NewAuditRepository(db *sqlx.DB) *auditRepository {
+	return &auditRepository{db: db}
+}
+
+type auditRow struct {
+	ID         uint      `db:"id"`
+	Actor      string    `db:"actor"`
+	RequestID  string    `db:"request_id"`
+	Operation  string    `db:"operation"`
+	Resource   string    `db:"resource"`
+	ResourceID uint      `db:"resource_id"`
+	Diff       []byte    `db:"diff"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+func (r auditRow) toDomain() domain.AuditEntry {
+	return domain.AuditEntry{
+		ID:         r.ID,
+		Actor:      r.Actor,
+		RequestID:  r.RequestID,
+		Operation:  domain.AuditOperation(r.Operation),
+		Resource:   r.Resource,
+		ResourceID: r.ResourceID,
+		Diff:       json.RawMessage(r.Diff),
+		CreatedAt:  r.CreatedAt,
+	}
+}
+
+func (r *auditRepository) CreateEntry(ctx context.Context, entry domain.AuditEntry) (domain.AuditEntry, *domain.AppError) {
+	err := r.db.GetContext(ctx, &entry.ID, `INSERT INTO audit_log
+			(actor, request_id, operation, resource, resource_id, diff, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`,
+		entry.Actor, entry.RequestID, entry.Operation, entry.Resource, entry.ResourceID,
+		[]byte(entry.Diff), entry.CreatedAt)
+	if err != nil {
+		return entry, domain.NewUnexpectedError(err.Error())
+	}
+
+	return entry, nil
+}
+
+func (r *auditRepository) ListEntries(ctx context.Context, resource string, resourceID uint, limit, offset int) ([]domain.AuditEntry, *domain.AppError) {
+	var rows []auditRow
+
+	err := r.db.SelectContext(ctx, &rows, `SELECT id, actor, request_id, operation, resource, resource_id, diff, created_at
+		FROM audit_log WHERE resource = $1 AND resource_id = $2
+		ORDER BY id DESC LIMIT $3 OFFSET $4`, resource, resourceID, limit, offset)
+	if err != nil {
+		return nil, domain.NewUnexpectedError(err.Error())
+	}
+
+	entries := make([]domain.AuditEntry, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, row.toDomain())
+	}
+
+	return entries, nil
+}
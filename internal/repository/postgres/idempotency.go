@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+	"github.com/jmoiron/sqlx"
+)
+
+type idempotencyRepository struct {
+	db *sqlx.DB
+}
+
+func NewIdempotencyRepository(db *sqlx.DB) *idempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+type idempotencyRow struct {
+	Key         string    `db:"key"`
+	Fingerprint string    `db:"fingerprint"`
+	Token       string    `db:"token"`
+	StatusCode  int       `db:"status_code"`
+	Header      []byte    `db:"header"`
+	Body        []byte    `db:"body"`
+	CreatedAt   time.Time `db:"created_at"`
+	ExpiresAt   time.Time `db:"expires_at"`
+}
+
+func (r idempotencyRow) toDomain() (domain.IdempotencyRecord, error) {
+	header := http.Header{}
+	if len(r.Header) > 0 {
+		if err := json.Unmarshal(r.Header, &header); err != nil {
+			return domain.IdempotencyRecord{}, err
+		}
+	}
+
+	return domain.IdempotencyRecord{
+		Key:         r.Key,
+		Fingerprint: r.Fingerprint,
+		Token:       r.Token,
+		StatusCode:  r.StatusCode,
+		Header:      header,
+		Body:        r.Body,
+		CreatedAt:   r.CreatedAt,
+		ExpiresAt:   r.ExpiresAt,
+	}, nil
+}
+
+func (r *idempotencyRepository) Reserve(ctx context.Context, record domain.IdempotencyRecord) (domain.IdempotencyRecord, bool, *domain.AppError) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return record, false, domain.NewUnexpectedError(err.Error())
+	}
+	defer tx.Rollback()
+
+	// Истекший ключ можно использовать повторно
+	if _, err := tx.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE key = $1 AND expires_at <= $2`,
+		record.Key, record.CreatedAt); err != nil {
+		return record, false, domain.NewUnexpectedError(err.Error())
+	}
+
+	res, err := tx.ExecContext(ctx, `INSERT INTO idempotency_keys (key, fingerprint, token, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5) ON CONFLICT (key) DO NOTHING`,
+		record.Key, record.Fingerprint, record.Token, record.CreatedAt, record.ExpiresAt)
+	if err != nil {
+		return record, false, domain.NewUnexpectedError(err.Error())
+	}
+
+	if n, _ := res.RowsAffected(); n == 1 {
+		if err := tx.Commit(); err != nil {
+			return record, false, domain.NewUnexpectedError(err.Error())
+		}
+		return record, true, nil
+	}
+
+	var row idempotencyRow
+	err = tx.GetContext(ctx, &row, `SELECT key, fingerprint, token, status_code, header, body, created_at, expires_at
+		FROM idempotency_keys WHERE key = $1`, record.Key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return record, false, domain.NewUnexpectedError("idempotency key disappeared during reservation")
+	}
+	if err != nil {
+		return record, false, domain.NewUnexpectedError(err.Error())
+	}
+
+	existing, err := row.toDomain()
+	if err != nil {
+		return record, false, domain.NewUnexpectedError(err.Error())
+	}
+
+	return existing, false, nil
+}
+
+func (r *idempotencyRepository) Complete(ctx context.Context, record domain.IdempotencyRecord) *domain.AppError {
+	header, err := json.Marshal(record.Header)
+	if err != nil {
+		return domain.NewUnexpectedError(err.Error())
+	}
+
+	res, err := r.db.ExecContext(ctx, `UPDATE idempotency_keys SET status_code = $3, header = $4, body = $5, expires_at = $6
+		WHERE key = $1 AND token = $2`, record.Key, record.Token, record.StatusCode, header, record.Body, record.ExpiresAt)
+	if err != nil {
+		return domain.NewUnexpectedError(err.Error())
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.NewNotFoundError("idempotency key reservation has expired")
+	}
+	return nil
+}
+
+func (r *idempotencyRepository) Delete(ctx context.Context, key, token string) *domain.AppError {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE key = $1 AND token = $2`, key, token)
+	if err != nil {
+		return domain.NewUnexpectedError(err.Error())
+	}
+	return nil
+}
+
+func (r *idempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, *domain.AppError) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, domain.NewUnexpectedError(err.Error())
+	}
+
+	n, _ := res.RowsAffected()
+	return n, nil
+}
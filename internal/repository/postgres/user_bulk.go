@@ -0,0 +1,192 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+)
+
+type userBulkRepository struct {
+	db *sqlx.DB
+}
+
+func NewUserBulkRepository(db *sqlx.DB) *userBulkRepository {
+	return &userBulkRepository{db: db}
+}
+
+type userRow struct {
+	ID          uint      `db:"id"`
+	Name        string    `db:"name"`
+	Age         int       `db:"age"`
+	CreatedDate time.Time `db:"created_date"`
+}
+
+func (r userRow) toDomain() domain.User {
+	return domain.User{
+		ID:          r.ID,
+		Name:        r.Name,
+		Age:         r.Age,
+		CreatedDate: r.CreatedDate,
+	}
+}
+
+// CopyUsers загружает пакет через COPY. ID выделяются из последовательности
+// заранее, чтобы сопоставить их записям без RETURNING
+func (r *userBulkRepository) CopyUsers(ctx context.Context, users []domain.User) ([]domain.User, *domain.AppError) {
+	users = slices.Clone(users)
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return nil, domain.NewUnexpectedError(err.Error())
+	}
+	defer conn.Close()
+
+	err = conn.Raw(func(driverConn any) error {
+		stdConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("bulk copy requires the pgx driver, got %T", driverConn)
+		}
+		pgConn := stdConn.Conn()
+
+		tx, err := pgConn.Begin(ctx)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback(ctx)
+
+		rows, err := tx.Query(ctx, `SELECT nextval(pg_get_serial_sequence('users', 'id'))
+			FROM generate_series(1, $1)`, len(users))
+		if err != nil {
+			return err
+		}
+		ids, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+		if err != nil {
+			return err
+		}
+
+		for i := range users {
+			users[i].ID = uint(ids[i])
+		}
+
+		_, err = tx.CopyFrom(ctx,
+			pgx.Identifier{"users"},
+			[]string{"id", "name", "age", "created_date"},
+			pgx.CopyFromSlice(len(users), func(i int) ([]any, error) {
+				u := users[i]
+				return []any{int64(u.ID), u.Name, u.Age, u.CreatedDate}, nil
+			}),
+		)
+		if err != nil {
+			return err
+		}
+
+		return tx.Commit(ctx)
+	})
+	if err != nil {
+		return nil, domain.NewUnexpectedError(err.Error())
+	}
+
+	return users, nil
+}
+
+func (r *userBulkRepository) ListUsers(ctx context.Context, filter domain.UserFilter, limit, offset int) ([]domain.User, *domain.AppError) {
+	where, args := userFilterClause(filter)
+	args = append(args, limit, offset)
+
+	var rows []userRow
+	query := fmt.Sprintf(`SELECT id, name, age, created_date FROM users%s
+		ORDER BY id LIMIT $%d OFFSET $%d`, where, len(args)-1, len(args))
+	if err := r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, domain.NewUnexpectedError(err.Error())
+	}
+
+	users := make([]domain.User, 0, len(rows))
+	for _, row := range rows {
+		users = append(users, row.toDomain())
+	}
+
+	return users, nil
+}
+
+func (r *userBulkRepository) CountUsers(ctx context.Context, filter domain.UserFilter) (int, *domain.AppError) {
+	where, args := userFilterClause(filter)
+
+	var count int
+	if err := r.db.GetContext(ctx, &count, "SELECT count(*) FROM users"+where, args...); err != nil {
+		return 0, domain.NewUnexpectedError(err.Error())
+	}
+
+	return count, nil
+}
+
+func (r *userBulkRepository) StreamUsers(ctx context.Context, filter domain.UserFilter, fn func(domain.User) error) *domain.AppError {
+	where, args := userFilterClause(filter)
+
+	rows, err := r.db.QueryxContext(ctx, `SELECT id, name, age, created_date FROM users`+where+` ORDER BY id`, args...)
+	if err != nil {
+		return domain.NewUnexpectedError(err.Error())
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row userRow
+		if err := rows.StructScan(&row); err != nil {
+			return domain.NewUnexpectedError(err.Error())
+		}
+		if err := fn(row.toDomain()); err != nil {
+			return domain.NewUnexpectedError(err.Error())
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return domain.NewUnexpectedError(err.Error())
+	}
+
+	return nil
+}
+
+// userFilterClause строит условие WHERE с позиционными параметрами
+func userFilterClause(filter domain.UserFilter) (string, []any) {
+	var (
+		conds []string
+		args  []any
+	)
+
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.Name != "" {
+		add("name ILIKE $%d", "%"+escapeLike(filter.Name)+"%")
+	}
+	if filter.MinAge != nil {
+		add("age >= $%d", *filter.MinAge)
+	}
+	if filter.MaxAge != nil {
+		add("age <= $%d", *filter.MaxAge)
+	}
+	if filter.CreatedAfter != nil {
+		add("created_date >= $%d", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		add("created_date < $%d", *filter.CreatedBefore)
+	}
+
+	if len(conds) == 0 {
+		return "", nil
+	}
+
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,302 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+	"github.com/jmoiron/sqlx"
+)
+
+type webhookRepository struct {
+	db *sqlx.DB
+}
+
+func NewWebhookRepository(db *sqlx.DB) *webhookRepository {
+	return &webhookRepository{db: db}
+}
+
+// subscriptionRow строка таблицы webhook_subscriptions.
+// События хранятся одной строкой через запятую
+type subscriptionRow struct {
+	ID        uint      `db:"id"`
+	URL       string    `db:"url"`
+	Secret    string    `db:"secret"`
+	Events    string    `db:"events"`
+	Active    bool      `db:"active"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+func newSubscriptionRow(sub domain.WebhookSubscription) subscriptionRow {
+	events := make([]string, 0, len(sub.Events))
+	for _, e := range sub.Events {
+		events = append(events, string(e))
+	}
+
+	return subscriptionRow{
+		ID:        sub.ID,
+		URL:       sub.URL,
+		Secret:    sub.Secret,
+		Events:    strings.Join(events, ","),
+		Active:    sub.Active,
+		CreatedAt: sub.CreatedAt,
+		UpdatedAt: sub.UpdatedAt,
+	}
+}
+
+func (r subscriptionRow) toDomain() domain.WebhookSubscription {
+	var events []domain.WebhookEvent
+	for _, e := range strings.Split(r.Events, ",") {
+		if e != "" {
+			events = append(events, domain.WebhookEvent(e))
+		}
+	}
+
+	return domain.WebhookSubscription{
+		ID:        r.ID,
+		URL:       r.URL,
+		Secret:    r.Secret,
+		Events:    events,
+		Active:    r.Active,
+		CreatedAt: r.CreatedAt,
+		UpdatedAt: r.UpdatedAt,
+	}
+}
+
+type deliveryRow struct {
+	ID             uint      `db:"id"`
+	SubscriptionID uint      `db:"subscription_id"`
+	Event          string    `db:"event"`
+	Payload        []byte    `db:"payload"`
+	Status         string    `db:"status"`
+	Attempts       int       `db:"attempts"`
+	Retries        int       `db:"retries"`
+	BeforeRetry    int       `db:"attempts_before_retry"`
+	ResponseCode   int       `db:"response_code"`
+	LastError      string    `db:"last_error"`
+	DurationNs     int64     `db:"duration_ns"`
+	NextAttemptAt  time.Time `db:"next_attempt_at"`
+	CreatedAt      time.Time `db:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at"`
+}
+
+func newDeliveryRow(d domain.WebhookDelivery) deliveryRow {
+	return deliveryRow{
+		ID:             d.ID,
+		SubscriptionID: d.SubscriptionID,
+		Event:          string(d.Event),
+		Payload:        d.Payload,
+		Status:         string(d.Status),
+		Attempts:       d.Attempts,
+		Retries:        d.Retries,
+		BeforeRetry:    d.AttemptsBeforeRetry,
+		ResponseCode:   d.ResponseCode,
+		LastError:      d.LastError,
+		DurationNs:     int64(d.Duration),
+		NextAttemptAt:  d.NextAttemptAt,
+		CreatedAt:      d.CreatedAt,
+		UpdatedAt:      d.UpdatedAt,
+	}
+}
+
+func (r deliveryRow) toDomain() domain.WebhookDelivery {
+	return domain.WebhookDelivery{
+		ID:                  r.ID,
+		SubscriptionID:      r.SubscriptionID,
+		Event:               domain.WebhookEvent(r.Event),
+		Payload:             json.RawMessage(r.Payload),
+		Status:              domain.DeliveryStatus(r.Status),
+		Attempts:            r.Attempts,
+		Retries:             r.Retries,
+		AttemptsBeforeRetry: r.BeforeRetry,
+		ResponseCode:        r.ResponseCode,
+		LastError:           r.LastError,
+		Duration:            time.Duration(r.DurationNs),
+		NextAttemptAt:       r.NextAttemptAt,
+		CreatedAt:           r.CreatedAt,
+		UpdatedAt:           r.UpdatedAt,
+	}
+}
+
+const deliveryColumns = `id, subscription_id, event, payload, status, attempts, retries, attempts_before_retry, response_code,
+	last_error, duration_ns, next_attempt_at, created_at, updated_at`
+
+func (r *webhookRepository) CreateSubscription(ctx context.Context, sub domain.WebhookSubscription) (domain.WebhookSubscription, *domain.AppError) {
+	row := newSubscriptionRow(sub)
+
+	query := `INSERT INTO webhook_subscriptions (url, secret, events, active, created_at, updated_at)
+		VALUES (:url, :secret, :events, :active, :created_at, :updated_at)
+		RETURNING id`
+
+	stmt, err := r.db.PrepareNamedContext(ctx, query)
+	if err != nil {
+		return sub, domain.NewUnexpectedError(err.Error())
+	}
+	defer stmt.Close()
+
+	if err := stmt.GetContext(ctx, &sub.ID, row); err != nil {
+		return sub, domain.NewUnexpectedError(err.Error())
+	}
+
+	return sub, nil
+}
+
+func (r *webhookRepository) GetSubscriptionById(ctx context.Context, id uint) (domain.WebhookSubscription, *domain.AppError) {
+	var row subscriptionRow
+
+	err := r.db.GetContext(ctx, &row, `SELECT id, url, secret, events, active, created_at, updated_at
+		FROM webhook_subscriptions WHERE id = $1`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		errStr := fmt.Sprintf("Webhook subscription not found, ID: %d", id)
+		return domain.WebhookSubscription{}, domain.NewNotFoundError(errStr)
+	}
+
+	if err != nil {
+		return domain.WebhookSubscription{}, domain.NewUnexpectedError(err.Error())
+	}
+
+	return row.toDomain(), nil
+}
+
+func (r *webhookRepository) ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, *domain.AppError) {
+	var rows []subscriptionRow
+
+	err := r.db.SelectContext(ctx, &rows, `SELECT id, url, secret, events, active, created_at, updated_at
+		FROM webhook_subscriptions ORDER BY id`)
+	if err != nil {
+		return nil, domain.NewUnexpectedError(err.Error())
+	}
+
+	subs := make([]domain.WebhookSubscription, 0, len(rows))
+	for _, row := range rows {
+		subs = append(subs, row.toDomain())
+	}
+
+	return subs, nil
+}
+
+func (r *webhookRepository) UpdateSubscription(ctx context.Context, sub domain.WebhookSubscription) (domain.WebhookSubscription, *domain.AppError) {
+	res, err := r.db.NamedExecContext(ctx, `UPDATE webhook_subscriptions
+		SET url = :url, secret = :secret, events = :events, active = :active, updated_at = :updated_at
+		WHERE id = :id`, newSubscriptionRow(sub))
+	if err != nil {
+		return sub, domain.NewUnexpectedError(err.Error())
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		errStr := fmt.Sprintf("Webhook subscription not found, ID: %d", sub.ID)
+		return sub, domain.NewNotFoundError(errStr)
+	}
+
+	return sub, nil
+}
+
+func (r *webhookRepository) DeleteSubscriptionById(ctx context.Context, id uint) *domain.AppError {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return domain.NewUnexpectedError(err.Error())
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		errStr := fmt.Sprintf("Webhook subscription not found, ID: %d", id)
+		return domain.NewNotFoundError(errStr)
+	}
+
+	return nil
+}
+
+func (r *webhookRepository) CreateDelivery(ctx context.Context, delivery domain.WebhookDelivery) (domain.WebhookDelivery, *domain.AppError) {
+	query := `INSERT INTO webhook_deliveries (subscription_id, event, payload, status, attempts,
+			retries, attempts_before_retry, response_code, last_error, duration_ns, next_attempt_at, created_at, updated_at)
+		VALUES (:subscription_id, :event, :payload, :status, :attempts,
+			:retries, :attempts_before_retry, :response_code, :last_error, :duration_ns, :next_attempt_at, :created_at, :updated_at)
+		RETURNING id`
+
+	stmt, err := r.db.PrepareNamedContext(ctx, query)
+	if err != nil {
+		return delivery, domain.NewUnexpectedError(err.Error())
+	}
+	defer stmt.Close()
+
+	if err := stmt.GetContext(ctx, &delivery.ID, newDeliveryRow(delivery)); err != nil {
+		return delivery, domain.NewUnexpectedError(err.Error())
+	}
+
+	return delivery, nil
+}
+
+func (r *webhookRepository) GetDeliveryById(ctx context.Context, id uint) (domain.WebhookDelivery, *domain.AppError) {
+	var row deliveryRow
+
+	err := r.db.GetContext(ctx, &row, `SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE id = $1`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		errStr := fmt.Sprintf("Webhook delivery not found, ID: %d", id)
+		return domain.WebhookDelivery{}, domain.NewNotFoundError(errStr)
+	}
+
+	if err != nil {
+		return domain.WebhookDelivery{}, domain.NewUnexpectedError(err.Error())
+	}
+
+	return row.toDomain(), nil
+}
+
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, delivery domain.WebhookDelivery) (domain.WebhookDelivery, *domain.AppError) {
+	_, err := r.db.NamedExecContext(ctx, `UPDATE webhook_deliveries
+		SET status = :status, attempts = :attempts, retries = :retries,
+			attempts_before_retry = :attempts_before_retry, response_code = :response_code,
+			last_error = :last_error, duration_ns = :duration_ns,
+			next_attempt_at = :next_attempt_at, updated_at = :updated_at
+		WHERE id = :id`, newDeliveryRow(delivery))
+	if err != nil {
+		return delivery, domain.NewUnexpectedError(err.Error())
+	}
+	return delivery, nil
+}
+
+func (r *webhookRepository) ListDeliveries(ctx context.Context, subscriptionID uint, limit, offset int) ([]domain.WebhookDelivery, *domain.AppError) {
+	var rows []deliveryRow
+
+	err := r.db.SelectContext(ctx, &rows, `SELECT `+deliveryColumns+` FROM webhook_deliveries
+		WHERE subscription_id = $1 ORDER BY id DESC LIMIT $2 OFFSET $3`, subscriptionID, limit, offset)
+	if err != nil {
+		return nil, domain.NewUnexpectedError(err.Error())
+	}
+
+	return toDeliveries(rows), nil
+}
+
+// ClaimDueDeliveries SKIP LOCKED позволяет нескольким экземплярам
+// забирать разные доставки одновременно, не ожидая друг друга
+func (r *webhookRepository) ClaimDueDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]domain.WebhookDelivery, *domain.AppError) {
+	var rows []deliveryRow
+
+	err := r.db.SelectContext(ctx, &rows, `UPDATE webhook_deliveries SET next_attempt_at = $4
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status IN ($1, $2) AND next_attempt_at <= $3
+			ORDER BY next_attempt_at LIMIT $5
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+deliveryColumns,
+		domain.DeliveryPending, domain.DeliveryRetrying, now, leaseUntil, limit)
+	if err != nil {
+		return nil, domain.NewUnexpectedError(err.Error())
+	}
+
+	return toDeliveries(rows), nil
+}
+
+func toDeliveries(rows []deliveryRow) []domain.WebhookDelivery {
+	deliveries := make([]domain.WebhookDelivery, 0, len(rows))
+	for _, row := range rows {
+		deliveries = append(deliveries, row.toDomain())
+	}
+	return deliveries
+}
@@ -0,0 +1,108 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"time"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const (
+	ResourceUser = "user"
+
+	anonymousActor = "anonymous"
+)
+
+type auditUseCase struct {
+	repo domain.AuditRepository
+	log  *slog.Logger
+}
+
+func NewAuditUseCase(repo domain.AuditRepository, log *slog.Logger) *auditUseCase {
+	return &auditUseCase{repo: repo, log: log}
+}
+
+func (u *auditUseCase) UserHistory(ctx context.Context, userID uint, limit, offset int) ([]domain.AuditEntry, *domain.AppError) {
+	entries, err := u.repo.ListEntries(ctx, ResourceUser, userID, limit, offset)
+	if err != nil {
+		u.log.ErrorContext(ctx, err.Message)
+		return nil, err
+	}
+	return entries, nil
+}
+
+// fieldChange старое и новое значение поля
+type fieldChange struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
+
+// Diff возвращает JSON с полями, которые отличаются в before и after.
+// nil в before или after означает создание или удаление записи
+func Diff(before, after any) (json.RawMessage, error) {
+	oldFields, err := toFields(before)
+	if err != nil {
+		return nil, err
+	}
+
+	newFields, err := toFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]fieldChange)
+	for name, oldValue := range oldFields {
+		if newValue, ok := newFields[name]; !ok || !reflect.DeepEqual(oldValue, newValue) {
+			changes[name] = fieldChange{Old: oldValue, New: newFields[name]}
+		}
+	}
+	for name, newValue := range newFields {
+		if _, ok := oldFields[name]; !ok {
+			changes[name] = fieldChange{New: newValue}
+		}
+	}
+
+	return json.Marshal(changes)
+}
+
+// toFields представляет структуру как набор полей в том виде,
+// в котором она отдается через API
+func toFields(v any) (map[string]any, error) {
+	if v == nil {
+		return map[string]any{}, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]any)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	return fields, nil
+}
+
+// newEntry заполняет автора и request_id из контекста запроса
+func newEntry(ctx context.Context, op domain.AuditOperation, resource string, resourceID uint, diff json.RawMessage) domain.AuditEntry {
+	actor := anonymousActor
+	if principal, ok := domain.PrincipalFromContext(ctx); ok {
+		actor = principal.ID
+	}
+
+	return domain.AuditEntry{
+		Actor:      actor,
+		RequestID:  middleware.GetReqID(ctx),
+		Operation:  op,
+		Resource:   resource,
+		ResourceID: resourceID,
+		Diff:       diff,
+		CreatedAt:  time.Now(),
+	}
+}
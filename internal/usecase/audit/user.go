@@ -0,0 +1,89 @@
+package audit
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/logger/sl"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/metrics"
+)
+
+// userUseCase декоратор над domain.UserUseCase, записывающий
+// каждое изменение пользователя в журнал аудита
+type userUseCase struct {
+	next     domain.UserUseCase
+	users    domain.UserRepository
+	auditLog domain.AuditRepository
+	log      *slog.Logger
+}
+
+// NewUserUseCase users используется для чтения состояния записи до
+// изменения. Это должен быть репозиторий без кэша: кэш другой реплики
+// может хранить устаревшую запись
+func NewUserUseCase(next domain.UserUseCase, users domain.UserRepository, auditLog domain.AuditRepository, log *slog.Logger) *userUseCase {
+	return &userUseCase{next: next, users: users, auditLog: auditLog, log: log}
+}
+
+func (u *userUseCase) CreateUser(ctx context.Context, user domain.User) (domain.User, *domain.AppError) {
+	created, err := u.next.CreateUser(ctx, user)
+	if err != nil {
+		return created, err
+	}
+
+	record(ctx, u.auditLog, u.log, domain.AuditCreate, created.ID, nil, created)
+	return created, nil
+}
+
+func (u *userUseCase) GetUserById(ctx context.Context, id uint) (domain.User, *domain.AppError) {
+	return u.next.GetUserById(ctx, id)
+}
+
+func (u *userUseCase) UpdateUser(ctx context.Context, user domain.User) (domain.User, *domain.AppError) {
+	before, err := u.users.GetUserById(ctx, user.ID)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	updated, err := u.next.UpdateUser(ctx, user)
+	if err != nil {
+		return updated, err
+	}
+
+	record(ctx, u.auditLog, u.log, domain.AuditUpdate, updated.ID, before, updated)
+	return updated, nil
+}
+
+func (u *userUseCase) DeleteUserById(ctx context.Context, id uint) *domain.AppError {
+	before, err := u.users.GetUserById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := u.next.DeleteUserById(ctx, id); err != nil {
+		return err
+	}
+
+	record(ctx, u.auditLog, u.log, domain.AuditDelete, id, before, nil)
+	return nil
+}
+
+// record не отменяет уже выполненную операцию: ошибка записи в журнал
+// логируется и учитывается в audit_write_failures_total
+func record(ctx context.Context, auditLog domain.AuditRepository, log *slog.Logger, op domain.AuditOperation, id uint, before, after any) {
+	diff, err := Diff(before, after)
+	if err != nil {
+		metrics.AuditWriteFailures.WithLabelValues(string(op)).Inc()
+		log.ErrorContext(ctx, "failed to build audit diff", slog.String("operation", string(op)), sl.Err(err))
+		return
+	}
+
+	if _, appErr := auditLog.CreateEntry(ctx, newEntry(ctx, op, ResourceUser, id, diff)); appErr != nil {
+		metrics.AuditWriteFailures.WithLabelValues(string(op)).Inc()
+		log.ErrorContext(ctx, "failed to write audit entry",
+			slog.String("operation", string(op)),
+			slog.Uint64("user_id", uint64(id)),
+			slog.String("error", appErr.Message),
+		)
+	}
+}
@@ -0,0 +1,34 @@
+package audit
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+)
+
+// userBulkUseCase записывает в журнал аудита каждого импортированного пользователя
+type userBulkUseCase struct {
+	domain.UserBulkUseCase
+	auditLog domain.AuditRepository
+	log      *slog.Logger
+}
+
+func NewUserBulkUseCase(next domain.UserBulkUseCase, auditLog domain.AuditRepository, log *slog.Logger) *userBulkUseCase {
+	return &userBulkUseCase{UserBulkUseCase: next, auditLog: auditLog, log: log}
+}
+
+func (u *userBulkUseCase) ImportUsers(ctx context.Context, users []domain.User) ([]domain.BulkItemResult, *domain.AppError) {
+	results, err := u.UserBulkUseCase.ImportUsers(ctx, users)
+	if err != nil {
+		return results, err
+	}
+
+	for _, result := range results {
+		if result.Status == domain.BulkItemCreated {
+			record(ctx, u.auditLog, u.log, domain.AuditCreate, result.User.ID, nil, result.User)
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,25 @@
+package authz
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+)
+
+// auditUseCase история пользователя доступна тем, кто может читать пользователя
+type auditUseCase struct {
+	next domain.AuditUseCase
+	authorizer
+}
+
+func NewAuditUseCase(next domain.AuditUseCase, policy *Policy, log *slog.Logger) *auditUseCase {
+	return &auditUseCase{next: next, authorizer: authorizer{resource: "user", policy: policy, log: log}}
+}
+
+func (u *auditUseCase) UserHistory(ctx context.Context, userID uint, limit, offset int) ([]domain.AuditEntry, *domain.AppError) {
+	if err := u.authorize(ctx, domain.PermissionRead, userID); err != nil {
+		return nil, err
+	}
+	return u.next.UserHistory(ctx, userID, limit, offset)
+}
@@ -0,0 +1,36 @@
+package authz
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/metrics"
+)
+
+// authorizer общая проверка прав для декораторов use case
+type authorizer struct {
+	resource string
+	policy   *Policy
+	log      *slog.Logger
+}
+
+// authorize возвращает ошибку 403, если операция запрещена.
+// Каждый отказ пишется в журнал аудита
+func (a authorizer) authorize(ctx context.Context, perm domain.Permission, targetID uint) *domain.AppError {
+	principal, ok := domain.PrincipalFromContext(ctx)
+	if ok && a.policy.Allowed(principal, perm, targetID) {
+		return nil
+	}
+
+	metrics.AuthzDenied.WithLabelValues(a.resource, string(perm)).Inc()
+	a.log.WarnContext(ctx, "authorization denied",
+		slog.Bool("audit", true),
+		slog.String("operation", string(perm)),
+		slog.String("resource", a.resource),
+		slog.Uint64("target_id", uint64(targetID)),
+	)
+
+	return domain.NewForbiddenError(fmt.Sprintf("Operation %q on %s is not permitted.", perm, a.resource))
+}
@@ -0,0 +1,87 @@
+package authz
+
+import (
+	"fmt"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+)
+
+// knownPermissions операции, которые можно выдать роли
+var knownPermissions = map[domain.Permission]bool{
+	domain.PermissionCreate: true,
+	domain.PermissionRead:   true,
+	domain.PermissionUpdate: true,
+	domain.PermissionDelete: true,
+	domain.PermissionList:   true,
+}
+
+// Policy сопоставляет ролям разрешенные операции
+type Policy struct {
+	grants map[domain.Role]map[domain.Permission]bool
+	// ownerPermissions операции, которые пользователь может
+	// выполнять над своей записью независимо от ролей
+	ownerPermissions map[domain.Permission]bool
+}
+
+// DefaultPolicy reader читает, editor дополнительно создает и изменяет,
+// admin может все. Владелец записи может читать и изменять ее
+func DefaultPolicy() *Policy {
+	return newPolicy(map[string][]string{
+		string(domain.RoleReader): {"read", "list"},
+		string(domain.RoleEditor): {"read", "list", "create", "update"},
+		string(domain.RoleAdmin):  {"read", "list", "create", "update", "delete"},
+	})
+}
+
+// NewPolicy строит политику из конфигурации вида роль -> операции.
+// Роль без операций и неизвестная операция считаются ошибкой
+// конфигурации: опечатка иначе молча лишила бы роль доступа
+func NewPolicy(roles map[string][]string) (*Policy, error) {
+	for role, permissions := range roles {
+		if role == "" {
+			return nil, fmt.Errorf("role name must not be empty")
+		}
+		if len(permissions) == 0 {
+			return nil, fmt.Errorf("role %s has no permissions", role)
+		}
+		for _, perm := range permissions {
+			if !knownPermissions[domain.Permission(perm)] {
+				return nil, fmt.Errorf("role %s: unknown permission %q", role, perm)
+			}
+		}
+	}
+
+	return newPolicy(roles), nil
+}
+
+func newPolicy(roles map[string][]string) *Policy {
+	p := &Policy{
+		grants: make(map[domain.Role]map[domain.Permission]bool, len(roles)),
+		ownerPermissions: map[domain.Permission]bool{
+			domain.PermissionRead:   true,
+			domain.PermissionUpdate: true,
+		},
+	}
+
+	for role, permissions := range roles {
+		granted := make(map[domain.Permission]bool, len(permissions))
+		for _, perm := range permissions {
+			granted[domain.Permission(perm)] = true
+		}
+		p.grants[domain.Role(role)] = granted
+	}
+
+	return p
+}
+
+// Allowed проверяет, может ли principal выполнить операцию над записью ownerID.
+// ownerID равен 0 для операций без конкретной записи
+func (p *Policy) Allowed(principal domain.Principal, perm domain.Permission, ownerID uint) bool {
+	for _, role := range principal.Roles {
+		if p.grants[domain.Role(role)][perm] {
+			return true
+		}
+	}
+
+	return ownerID != 0 && principal.UserID == ownerID && p.ownerPermissions[perm]
+}
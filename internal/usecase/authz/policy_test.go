@@ -0,0 +1,26 @@
+package authz
+
+import "testing"
+
+func TestNewPolicyRejectsInvalidRoles(t *testing.T) {
+	tests := []struct {
+		name  string
+		roles map[string][]string
+	}{
+		{name: "unknown permission", roles: map[string][]string{"editor": {"read", "updte"}}},
+		{name: "role without permissions", roles: map[string][]string{"reader": {}}},
+		{name: "empty role name", roles: map[string][]string{"": {"read"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewPolicy(tt.roles); err == nil {
+				t.Fatalf("NewPolicy(%v) = nil error, want rejected", tt.roles)
+			}
+		})
+	}
+
+	if _, err := NewPolicy(map[string][]string{"reader": {"read", "list"}}); err != nil {
+		t.Fatalf("NewPolicy() = %v, want valid policy", err)
+	}
+}
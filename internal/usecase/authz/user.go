@@ -0,0 +1,47 @@
+package authz
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+)
+
+// userUseCase декоратор над domain.UserUseCase, проверяющий права
+// вызывающего до выполнения операции
+type userUseCase struct {
+	next domain.UserUseCase
+	authorizer
+}
+
+func NewUserUseCase(next domain.UserUseCase, policy *Policy, log *slog.Logger) *userUseCase {
+	return &userUseCase{next: next, authorizer: authorizer{resource: "user", policy: policy, log: log}}
+}
+
+func (u *userUseCase) CreateUser(ctx context.Context, user domain.User) (domain.User, *domain.AppError) {
+	if err := u.authorize(ctx, domain.PermissionCreate, 0); err != nil {
+		return domain.User{}, err
+	}
+	return u.next.CreateUser(ctx, user)
+}
+
+func (u *userUseCase) GetUserById(ctx context.Context, id uint) (domain.User, *domain.AppError) {
+	if err := u.authorize(ctx, domain.PermissionRead, id); err != nil {
+		return domain.User{}, err
+	}
+	return u.next.GetUserById(ctx, id)
+}
+
+func (u *userUseCase) UpdateUser(ctx context.Context, user domain.User) (domain.User, *domain.AppError) {
+	if err := u.authorize(ctx, domain.PermissionUpdate, user.ID); err != nil {
+		return domain.User{}, err
+	}
+	return u.next.UpdateUser(ctx, user)
+}
+
+func (u *userUseCase) DeleteUserById(ctx context.Context, id uint) *domain.AppError {
+	if err := u.authorize(ctx, domain.PermissionDelete, id); err != nil {
+		return err
+	}
+	return u.next.DeleteUserById(ctx, id)
+}
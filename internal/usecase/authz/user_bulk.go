@@ -0,0 +1,39 @@
+package authz
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+)
+
+// userBulkUseCase импорт требует права create, выборка и экспорт — list
+type userBulkUseCase struct {
+	next domain.UserBulkUseCase
+	authorizer
+}
+
+func NewUserBulkUseCase(next domain.UserBulkUseCase, policy *Policy, log *slog.Logger) *userBulkUseCase {
+	return &userBulkUseCase{next: next, authorizer: authorizer{resource: "user", policy: policy, log: log}}
+}
+
+func (u *userBulkUseCase) ImportUsers(ctx context.Context, users []domain.User) ([]domain.BulkItemResult, *domain.AppError) {
+	if err := u.authorize(ctx, domain.PermissionCreate, 0); err != nil {
+		return nil, err
+	}
+	return u.next.ImportUsers(ctx, users)
+}
+
+func (u *userBulkUseCase) ListUsers(ctx context.Context, filter domain.UserFilter, limit, offset int) ([]domain.User, *domain.AppError) {
+	if err := u.authorize(ctx, domain.PermissionList, 0); err != nil {
+		return nil, err
+	}
+	return u.next.ListUsers(ctx, filter, limit, offset)
+}
+
+func (u *userBulkUseCase) ExportUsers(ctx context.Context, filter domain.UserFilter, fn func(domain.User) error) *domain.AppError {
+	if err := u.authorize(ctx, domain.PermissionList, 0); err != nil {
+		return err
+	}
+	return u.next.ExportUsers(ctx, filter, fn)
+}
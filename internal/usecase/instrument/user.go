@@ -0,0 +1,94 @@
+package instrument
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/metrics"
+)
+
+const (
+	outcomeSuccess = "success"
+	outcomeError   = "error"
+	unknownField   = "unknown"
+)
+
+// userUseCase декоратор над domain.UserUseCase, который записывает
+// бизнес-метрики, не затрагивая логику сценариев
+type userUseCase struct {
+	next domain.UserUseCase
+}
+
+func NewUserUseCase(next domain.UserUseCase) *userUseCase {
+	return &userUseCase{next: next}
+}
+
+func (u *userUseCase) CreateUser(ctx context.Context, user domain.User) (domain.User, *domain.AppError) {
+	start := time.Now()
+	created, err := u.next.CreateUser(ctx, user)
+	observe("CreateUser", start, err)
+	if err != nil {
+		countValidation("create", err)
+		return created, err
+	}
+
+	metrics.UsersChanged.WithLabelValues("created").Inc()
+	metrics.UserAgeAtCreation.Observe(float64(created.Age))
+	return created, nil
+}
+
+func (u *userUseCase) GetUserById(ctx context.Context, id uint) (domain.User, *domain.AppError) {
+	start := time.Now()
+	user, err := u.next.GetUserById(ctx, id)
+	observe("GetUserById", start, err)
+	return user, err
+}
+
+func (u *userUseCase) UpdateUser(ctx context.Context, user domain.User) (domain.User, *domain.AppError) {
+	start := time.Now()
+	updated, err := u.next.UpdateUser(ctx, user)
+	observe("UpdateUser", start, err)
+	if err != nil {
+		countValidation("update", err)
+		return updated, err
+	}
+
+	metrics.UsersChanged.WithLabelValues("updated").Inc()
+	return updated, nil
+}
+
+func (u *userUseCase) DeleteUserById(ctx context.Context, id uint) *domain.AppError {
+	start := time.Now()
+	err := u.next.DeleteUserById(ctx, id)
+	observe("DeleteUserById", start, err)
+	if err != nil {
+		return err
+	}
+
+	metrics.UsersChanged.WithLabelValues("deleted").Inc()
+	return nil
+}
+
+// observe ошибки клиента (4xx) не считаются сбоем сценария
+func observe(method string, start time.Time, err *domain.AppError) {
+	outcome := outcomeSuccess
+	if err != nil && err.Code >= http.StatusInternalServerError {
+		outcome = outcomeError
+	}
+
+	metrics.UseCaseDuration.WithLabelValues("user", method, outcome).Observe(time.Since(start).Seconds())
+}
+
+func countValidation(operation string, err *domain.AppError) {
+	if err.Code != http.StatusBadRequest {
+		return
+	}
+
+	field := err.Field
+	if field == "" {
+		field = unknownField
+	}
+	metrics.UserValidationFailures.WithLabelValues(operation, field).Inc()
+}
@@ -0,0 +1,54 @@
+package instrument
+
+import (
+	"context"
+	"time"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/metrics"
+)
+
+// userBulkUseCase декоратор над domain.UserBulkUseCase: импортированные
+// пользователи учитываются в тех же метриках, что и созданные по одному
+type userBulkUseCase struct {
+	next domain.UserBulkUseCase
+}
+
+func NewUserBulkUseCase(next domain.UserBulkUseCase) *userBulkUseCase {
+	return &userBulkUseCase{next: next}
+}
+
+func (u *userBulkUseCase) ImportUsers(ctx context.Context, users []domain.User) ([]domain.BulkItemResult, *domain.AppError) {
+	start := time.Now()
+	results, err := u.next.ImportUsers(ctx, users)
+	observe("ImportUsers", start, err)
+	if err != nil {
+		return results, err
+	}
+
+	for _, result := range results {
+		switch result.Status {
+		case domain.BulkItemCreated:
+			metrics.UsersChanged.WithLabelValues("created").Inc()
+			metrics.UserAgeAtCreation.Observe(float64(result.User.Age))
+		case domain.BulkItemFailed:
+			countValidation("import", result.Err)
+		}
+	}
+
+	return results, nil
+}
+
+func (u *userBulkUseCase) ListUsers(ctx context.Context, filter domain.UserFilter, limit, offset int) ([]domain.User, *domain.AppError) {
+	start := time.Now()
+	users, err := u.next.ListUsers(ctx, filter, limit, offset)
+	observe("ListUsers", start, err)
+	return users, err
+}
+
+func (u *userBulkUseCase) ExportUsers(ctx context.Context, filter domain.UserFilter, fn func(domain.User) error) *domain.AppError {
+	start := time.Now()
+	err := u.next.ExportUsers(ctx, filter, fn)
+	observe("ExportUsers", start, err)
+	return err
+}
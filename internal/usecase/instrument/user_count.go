@@ -0,0 +1,56 @@
+package instrument
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/metrics"
+)
+
+// UserCounter периодически обновляет метрику общего числа пользователей.
+// Считать при каждом изменении нельзя: часть изменений (импорт, удаление
+// напрямую в БД) проходит мимо UserUseCase
+type UserCounter struct {
+	repo     domain.UserBulkRepository
+	interval time.Duration
+	log      *slog.Logger
+}
+
+func NewUserCounter(repo domain.UserBulkRepository, interval time.Duration, log *slog.Logger) *UserCounter {
+	return &UserCounter{
+		repo:     repo,
+		interval: interval,
+		log:      log.With(slog.String("component", "usecase/instrument")),
+	}
+}
+
+// Run обновляет метрику сразу и затем раз в интервал до отмены ctx
+func (c *UserCounter) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		c.refresh(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *UserCounter) refresh(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, c.interval)
+	defer cancel()
+
+	count, err := c.repo.CountUsers(ctx, domain.UserFilter{})
+	if err != nil {
+		c.log.WarnContext(ctx, "failed to count users", slog.String("error", err.Message))
+		return
+	}
+
+	metrics.UsersTotal.Set(float64(count))
+}
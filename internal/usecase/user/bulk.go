@@ -0,0 +1,84 @@
+package user
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+)
+
+type userBulkUseCase struct {
+	repo domain.UserBulkRepository
+	log  *slog.Logger
+}
+
+func NewUserBulkUseCase(repo domain.UserBulkRepository, log *slog.Logger) *userBulkUseCase {
+	return &userBulkUseCase{repo: repo, log: log}
+}
+
+// ImportUsers невалидные записи отклоняются по одной, остальные сохраняются
+// одним пакетом. Ошибка сохранения помечает неудачными все записи пакета
+func (u *userBulkUseCase) ImportUsers(ctx context.Context, users []domain.User) ([]domain.BulkItemResult, *domain.AppError) {
+	results := make([]domain.BulkItemResult, len(users))
+	valid := make([]domain.User, 0, len(users))
+	positions := make([]int, 0, len(users))
+
+	now := time.Now()
+	for i, user := range users {
+		results[i].Index = i
+
+		if err := user.Validate(); err != nil {
+			results[i].Status = domain.BulkItemFailed
+			results[i].Err = err
+			continue
+		}
+
+		user.ID = 0
+		user.CreatedDate = now
+		valid = append(valid, user)
+		positions = append(positions, i)
+	}
+
+	if len(valid) == 0 {
+		return results, nil
+	}
+
+	created, err := u.repo.CopyUsers(ctx, valid)
+	if err != nil {
+		u.log.ErrorContext(ctx, "failed to import users batch",
+			slog.Int("size", len(valid)),
+			slog.String("error", err.Message),
+		)
+		for _, pos := range positions {
+			results[pos].Status = domain.BulkItemFailed
+			results[pos].Err = err
+		}
+		return results, nil
+	}
+
+	for i, pos := range positions {
+		results[pos].Status = domain.BulkItemCreated
+		results[pos].User = created[i]
+	}
+
+	u.log.InfoContext(ctx, "users batch imported", slog.Int("created", len(created)))
+	return results, nil
+}
+
+func (u *userBulkUseCase) ListUsers(ctx context.Context, filter domain.UserFilter, limit, offset int) ([]domain.User, *domain.AppError) {
+	users, err := u.repo.ListUsers(ctx, filter, limit, offset)
+	if err != nil {
+		u.log.ErrorContext(ctx, err.Message)
+		return nil, err
+	}
+	return users, nil
+}
+
+func (u *userBulkUseCase) ExportUsers(ctx context.Context, filter domain.UserFilter, fn func(domain.User) error) *domain.AppError {
+	if err := u.repo.StreamUsers(ctx, filter, fn); err != nil {
+		u.log.ErrorContext(ctx, err.Message)
+		return err
+	}
+	return nil
+}
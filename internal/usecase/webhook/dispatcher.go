@@ -0,0 +1,274 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/config"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/logger/sl"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/metrics"
+)
+
+// errSubscriptionLookup подписку не удалось прочитать из репозитория
+var errSubscriptionLookup = errors.New("failed to load subscription")
+
+// Dispatcher доставляет вебхуки подписчикам.
+// Доставки берутся из репозитория пачками, неудачные попытки
+// повторяются с экспоненциальной задержкой, после MaxAttempts
+// доставка переводится в статус dead
+type Dispatcher struct {
+	repo   domain.WebhookRepository
+	client *http.Client
+	cfg    config.Webhook
+	log    *slog.Logger
+	wake   chan struct{}
+	now    func() time.Time
+}
+
+// NewDispatcher создает диспетчер доставки вебхуков
+func NewDispatcher(repo domain.WebhookRepository, client *http.Client, cfg config.Webhook, log *slog.Logger) *Dispatcher {
+	if client == nil {
+		client = &http.Client{Timeout: cfg.Timeout}
+	}
+
+	return &Dispatcher{
+		repo:   repo,
+		client: client,
+		cfg:    cfg,
+		log:    log,
+		wake:   make(chan struct{}, 1),
+		now:    time.Now,
+	}
+}
+
+// Notify будит диспетчер, не дожидаясь следующего опроса
+func (d *Dispatcher) Notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run обрабатывает доставки до отмены контекста
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.processDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// processDue отправляет все доставки, время которых подошло.
+// Доставки пачки забираются в репозитории на время Lease, поэтому
+// несколько экземпляров сервиса не отправляют одну доставку дважды
+func (d *Dispatcher) processDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		now := d.now()
+		deliveries, err := d.repo.ClaimDueDeliveries(ctx, now, now.Add(d.cfg.Lease), d.cfg.BatchSize)
+		if err != nil {
+			d.log.ErrorContext(ctx, "failed to list due webhook deliveries", slog.String("error", err.Message))
+			return
+		}
+
+		if len(deliveries) == 0 {
+			return
+		}
+
+		sem := make(chan struct{}, max(d.cfg.Workers, 1))
+		var wg sync.WaitGroup
+
+		// начатые доставки завершаются и при остановке: их длительность
+		// ограничена таймаутом запроса. Новые после отмены ctx не начинаются
+		deliverCtx := context.WithoutCancel(ctx)
+
+	dispatch:
+		for _, delivery := range deliveries {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				break dispatch
+			}
+			wg.Add(1)
+
+			go func(delivery domain.WebhookDelivery) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				d.Deliver(deliverCtx, delivery)
+			}(delivery)
+		}
+
+		wg.Wait()
+
+		if len(deliveries) < d.cfg.BatchSize {
+			return
+		}
+	}
+}
+
+// Deliver выполняет одну попытку доставки и сохраняет ее результат
+func (d *Dispatcher) Deliver(ctx context.Context, delivery domain.WebhookDelivery) domain.WebhookDelivery {
+	log := d.log.With(
+		slog.Uint64("delivery_id", uint64(delivery.ID)),
+		slog.Uint64("subscription_id", uint64(delivery.SubscriptionID)),
+		slog.String("event", string(delivery.Event)),
+	)
+
+	var (
+		code    int
+		err     error
+		elapsed time.Duration
+	)
+
+	// В dead-letter уходят только доставки удаленных и выключенных подписок.
+	// Ошибка чтения подписки временная и повторяется, как неудачная отправка
+	sub, appErr := d.repo.GetSubscriptionById(ctx, delivery.SubscriptionID)
+	switch {
+	case appErr != nil && appErr.Code == http.StatusNotFound:
+		return d.bury(ctx, log, delivery, "subscription is removed")
+	case appErr != nil:
+		err = fmt.Errorf("%w: %s", errSubscriptionLookup, appErr.Message)
+	case !sub.Active:
+		return d.bury(ctx, log, delivery, "subscription is inactive")
+	default:
+		start := d.now()
+		code, err = d.send(ctx, sub, delivery)
+		elapsed = d.now().Sub(start)
+	}
+
+	delivery.Attempts++
+	delivery.ResponseCode = code
+	delivery.Duration = elapsed
+	delivery.UpdatedAt = d.now()
+
+	if err == nil {
+		delivery.Status = domain.DeliverySucceeded
+		delivery.LastError = ""
+		metrics.WebhookDeliveryDuration.WithLabelValues(string(delivery.Event), "success").Observe(elapsed.Seconds())
+		log.DebugContext(ctx, "webhook delivered", slog.Int("attempt", delivery.Attempts))
+	} else {
+		delivery.LastError = err.Error()
+		if !errors.Is(err, errSubscriptionLookup) {
+			metrics.WebhookDeliveryDuration.WithLabelValues(string(delivery.Event), "failure").Observe(elapsed.Seconds())
+		}
+		metrics.WebhookDeliveryFailures.WithLabelValues(string(delivery.Event), failureReason(code, err)).Inc()
+
+		if delivery.CurrentAttempts() >= d.cfg.MaxAttempts {
+			delivery.Status = domain.DeliveryDead
+			metrics.WebhookDeliveriesDead.WithLabelValues(string(delivery.Event)).Inc()
+			log.WarnContext(ctx, "webhook delivery moved to dead-letter", slog.Int("attempts", delivery.Attempts), sl.Err(err))
+		} else {
+			delivery.Status = domain.DeliveryRetrying
+			delivery.NextAttemptAt = d.now().Add(Backoff(delivery.CurrentAttempts(), d.cfg.BackoffBase, d.cfg.BackoffMax))
+			log.InfoContext(ctx, "webhook delivery failed, will retry",
+				slog.Int("attempt", delivery.Attempts),
+				slog.Time("next_attempt_at", delivery.NextAttemptAt),
+				sl.Err(err),
+			)
+		}
+	}
+
+	if _, err := d.repo.UpdateDelivery(ctx, delivery); err != nil {
+		log.ErrorContext(ctx, "failed to save webhook delivery", slog.String("error", err.Message))
+	}
+
+	return delivery
+}
+
+// bury переводит доставку в dead-letter без попытки отправки
+func (d *Dispatcher) bury(ctx context.Context, log *slog.Logger, delivery domain.WebhookDelivery, reason string) domain.WebhookDelivery {
+	delivery.Status = domain.DeliveryDead
+	delivery.LastError = reason
+	delivery.UpdatedAt = d.now()
+	metrics.WebhookDeliveriesDead.WithLabelValues(string(delivery.Event)).Inc()
+
+	if _, err := d.repo.UpdateDelivery(ctx, delivery); err != nil {
+		log.ErrorContext(ctx, "failed to save webhook delivery", slog.String("error", err.Message))
+	}
+	return delivery
+}
+
+// send отправляет подписанный запрос и возвращает код ответа
+func (d *Dispatcher) send(ctx context.Context, sub domain.WebhookSubscription, delivery domain.WebhookDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(delivery.Event))
+	req.Header.Set(DeliveryHeader, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(SignatureHeader, Sign(sub.Secret, d.now(), delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// Вычитываем тело, чтобы соединение вернулось в пул
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Backoff возвращает задержку перед следующей попыткой:
+// base * 2^(attempt-1) со случайным разбросом до 10%, но не больше maxDelay
+func Backoff(attempt int, base, maxDelay time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+
+	if jitter := int64(delay / 10); jitter > 0 {
+		delay += time.Duration(rand.Int64N(jitter))
+	}
+
+	return delay
+}
+
+func failureReason(code int, err error) string {
+	switch {
+	case errors.Is(err, errSubscriptionLookup):
+		return "subscription_lookup"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case code >= 500:
+		return "status_5xx"
+	case code >= 400:
+		return "status_4xx"
+	case code > 0:
+		return "status_other"
+	default:
+		return "network"
+	}
+}
@@ -0,0 +1,250 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/config"
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/internal/lib/logger/handlers/slogdiscard"
+)
+
+var testConfig = config.Webhook{
+	Workers:      2,
+	Timeout:      time.Second,
+	MaxAttempts:  3,
+	BackoffBase:  time.Second,
+	BackoffMax:   time.Minute,
+	PollInterval: time.Second,
+	BatchSize:    10,
+	Lease:        time.Minute,
+}
+
+// setup создает подписку на адрес receiver и одну ожидающую доставку
+func setup(t *testing.T, receiver http.HandlerFunc) (*Dispatcher, domain.WebhookRepository, domain.WebhookDelivery, time.Time) {
+	t.Helper()
+
+	srv := httptest.NewServer(receiver)
+	t.Cleanup(srv.Close)
+
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	repo := newMemoryRepository()
+
+	sub, appErr := repo.CreateSubscription(ctx, domain.WebhookSubscription{
+		URL:    srv.URL,
+		Secret: "secret",
+		Events: []domain.WebhookEvent{domain.EventUserCreated},
+		Active: true,
+	})
+	if appErr != nil {
+		t.Fatal(appErr.Message)
+	}
+
+	delivery, appErr := repo.CreateDelivery(ctx, domain.WebhookDelivery{
+		SubscriptionID: sub.ID,
+		Event:          domain.EventUserCreated,
+		Payload:        []byte(`{"event":"user.created","data":{"id":1}}`),
+		Status:         domain.DeliveryPending,
+		NextAttemptAt:  now,
+	})
+	if appErr != nil {
+		t.Fatal(appErr.Message)
+	}
+
+	d := NewDispatcher(repo, srv.Client(), testConfig, slogdiscard.NewDiscardLogger())
+	d.now = func() time.Time { return now }
+
+	return d, repo, delivery, now
+}
+
+func TestDeliverSignsRequest(t *testing.T) {
+	var (
+		header http.Header
+		body   []byte
+	)
+	d, repo, delivery, now := setup(t, func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	got := d.Deliver(context.Background(), delivery)
+
+	if err := Verify("secret", header.Get(SignatureHeader), body, time.Minute, now); err != nil {
+		t.Fatalf("signature: %v", err)
+	}
+	if header.Get(EventHeader) != string(domain.EventUserCreated) {
+		t.Fatalf("%s = %q, want %q", EventHeader, header.Get(EventHeader), domain.EventUserCreated)
+	}
+	if header.Get(DeliveryHeader) != strconv.FormatUint(uint64(delivery.ID), 10) {
+		t.Fatalf("%s = %q, want %d", DeliveryHeader, header.Get(DeliveryHeader), delivery.ID)
+	}
+	if got.Status != domain.DeliverySucceeded || got.Attempts != 1 || got.ResponseCode != http.StatusNoContent {
+		t.Fatalf("delivery = %+v, want succeeded after 1 attempt with 204", got)
+	}
+
+	stored, _ := repo.GetDeliveryById(context.Background(), delivery.ID)
+	if stored.Status != domain.DeliverySucceeded {
+		t.Fatalf("stored status = %s, want %s", stored.Status, domain.DeliverySucceeded)
+	}
+}
+
+func TestDeliverRetriesUntilDead(t *testing.T) {
+	var calls atomic.Int32
+	d, _, delivery, now := setup(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	for attempt := 1; attempt < testConfig.MaxAttempts; attempt++ {
+		delivery = d.Deliver(context.Background(), delivery)
+
+		if delivery.Status != domain.DeliveryRetrying || delivery.Attempts != attempt {
+			t.Fatalf("attempt %d: delivery = %+v, want retrying", attempt, delivery)
+		}
+
+		minDelay := testConfig.BackoffBase << (attempt - 1)
+		delay := delivery.NextAttemptAt.Sub(now)
+		if delay < minDelay || delay > minDelay+minDelay/10 {
+			t.Fatalf("attempt %d: next attempt in %s, want %s + up to 10%%", attempt, delay, minDelay)
+		}
+	}
+
+	delivery = d.Deliver(context.Background(), delivery)
+	if delivery.Status != domain.DeliveryDead || delivery.Attempts != testConfig.MaxAttempts {
+		t.Fatalf("delivery = %+v, want dead after %d attempts", delivery, testConfig.MaxAttempts)
+	}
+	if delivery.ResponseCode != http.StatusInternalServerError || delivery.LastError == "" {
+		t.Fatalf("delivery = %+v, want last response 500 with error", delivery)
+	}
+	if int(calls.Load()) != testConfig.MaxAttempts {
+		t.Fatalf("receiver got %d requests, want %d", calls.Load(), testConfig.MaxAttempts)
+	}
+}
+
+// TestRetryDeliveryKeepsAttempts ручной повтор не стирает историю попыток,
+// но лимит и задержка отсчитываются заново
+func TestRetryDeliveryKeepsAttempts(t *testing.T) {
+	d, repo, delivery, now := setup(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	ctx := context.Background()
+
+	for range testConfig.MaxAttempts {
+		delivery = d.Deliver(ctx, delivery)
+	}
+	if delivery.Status != domain.DeliveryDead {
+		t.Fatalf("delivery = %+v, want dead", delivery)
+	}
+
+	uc := NewWebhookUseCase(repo, d, slogdiscard.NewDiscardLogger())
+	delivery, appErr := uc.RetryDelivery(ctx, delivery.SubscriptionID, delivery.ID)
+	if appErr != nil {
+		t.Fatal(appErr.Message)
+	}
+	if delivery.Status != domain.DeliveryPending || delivery.Attempts != testConfig.MaxAttempts || delivery.Retries != 1 {
+		t.Fatalf("retried delivery = %+v, want pending with %d attempts and 1 retry", delivery, testConfig.MaxAttempts)
+	}
+
+	delivery = d.Deliver(ctx, delivery)
+	if delivery.Status != domain.DeliveryRetrying || delivery.Attempts != testConfig.MaxAttempts+1 {
+		t.Fatalf("delivery = %+v, want retrying after attempt %d", delivery, testConfig.MaxAttempts+1)
+	}
+	if delay := delivery.NextAttemptAt.Sub(now); delay > testConfig.BackoffBase+testConfig.BackoffBase/10 {
+		t.Fatalf("next attempt in %s, want the first backoff step %s", delay, testConfig.BackoffBase)
+	}
+}
+
+// unavailableRepo репозиторий, в котором не читаются подписки
+type unavailableRepo struct {
+	domain.WebhookRepository
+}
+
+func (unavailableRepo) GetSubscriptionById(context.Context, uint) (domain.WebhookSubscription, *domain.AppError) {
+	return domain.WebhookSubscription{}, domain.NewUnexpectedError("connection refused")
+}
+
+func TestDeliverRetriesWhenSubscriptionLookupFails(t *testing.T) {
+	var calls atomic.Int32
+	d, repo, delivery, now := setup(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	})
+	d.repo = unavailableRepo{repo}
+
+	got := d.Deliver(context.Background(), delivery)
+
+	if got.Status != domain.DeliveryRetrying || got.Attempts != 1 {
+		t.Fatalf("delivery = %+v, want retrying after 1 attempt", got)
+	}
+	if !got.NextAttemptAt.After(now) || !strings.Contains(got.LastError, "connection refused") {
+		t.Fatalf("delivery = %+v, want rescheduled with the lookup error", got)
+	}
+	if calls.Load() != 0 {
+		t.Fatalf("receiver got %d requests, want none", calls.Load())
+	}
+}
+
+func TestDeliverDeadLettersRemovedSubscription(t *testing.T) {
+	d, repo, delivery, _ := setup(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	if err := repo.DeleteSubscriptionById(context.Background(), delivery.SubscriptionID); err != nil {
+		t.Fatal(err.Message)
+	}
+
+	got := d.Deliver(context.Background(), delivery)
+	if got.Status != domain.DeliveryDead || got.Attempts != 0 {
+		t.Fatalf("delivery = %+v, want dead without attempts", got)
+	}
+}
+
+func TestProcessDueClaimsDeliveries(t *testing.T) {
+	var calls atomic.Int32
+	d, repo, delivery, now := setup(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	d.processDue(context.Background())
+
+	if calls.Load() != 1 {
+		t.Fatalf("receiver got %d requests, want 1", calls.Load())
+	}
+
+	claimed, _ := repo.ClaimDueDeliveries(context.Background(), now.Add(time.Hour), now.Add(2*time.Hour), 10)
+	if len(claimed) != 0 {
+		t.Fatalf("delivered delivery %d claimed again", delivery.ID)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	base, maxDelay := time.Second, 10*time.Second
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 1, want: time.Second},
+		{attempt: 2, want: 2 * time.Second},
+		{attempt: 3, want: 4 * time.Second},
+		{attempt: 4, want: 8 * time.Second},
+		{attempt: 5, want: maxDelay},
+		{attempt: 20, want: maxDelay},
+	}
+
+	for _, tt := range tests {
+		for range 20 {
+			got := Backoff(tt.attempt, base, maxDelay)
+			if got < tt.want || got >= tt.want+tt.want/10 {
+				t.Fatalf("Backoff(%d) = %s, want %s + jitter below 10%%", tt.attempt, got, tt.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+)
+
+// memoryRepository хранит подписки и доставки в памяти процесса
+// для тестов диспетчера
+type memoryRepository struct {
+	mu             sync.RWMutex
+	subscriptions  map[uint]domain.WebhookSubscription
+	deliveries     map[uint]domain.WebhookDelivery
+	nextSubID      uint
+	nextDeliveryID uint
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{
+		subscriptions: make(map[uint]domain.WebhookSubscription),
+		deliveries:    make(map[uint]domain.WebhookDelivery),
+	}
+}
+
+func (r *memoryRepository) CreateSubscription(ctx context.Context, sub domain.WebhookSubscription) (domain.WebhookSubscription, *domain.AppError) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextSubID++
+	sub.ID = r.nextSubID
+	r.subscriptions[sub.ID] = sub
+
+	return sub, nil
+}
+
+func (r *memoryRepository) GetSubscriptionById(ctx context.Context, id uint) (domain.WebhookSubscription, *domain.AppError) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sub, ok := r.subscriptions[id]
+	if !ok {
+		errStr := fmt.Sprintf("Webhook subscription not found, ID: %d", id)
+		return sub, domain.NewNotFoundError(errStr)
+	}
+
+	return sub, nil
+}
+
+func (r *memoryRepository) ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, *domain.AppError) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	subs := make([]domain.WebhookSubscription, 0, len(r.subscriptions))
+	for _, sub := range r.subscriptions {
+		subs = append(subs, sub)
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].ID < subs[j].ID })
+
+	return subs, nil
+}
+
+func (r *memoryRepository) UpdateSubscription(ctx context.Context, sub domain.WebhookSubscription) (domain.WebhookSubscription, *domain.AppError) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.subscriptions[sub.ID]; !ok {
+		errStr := fmt.Sprintf("Webhook subscription not found, ID: %d", sub.ID)
+		return sub, domain.NewNotFoundError(errStr)
+	}
+	r.subscriptions[sub.ID] = sub
+
+	return sub, nil
+}
+
+func (r *memoryRepository) DeleteSubscriptionById(ctx context.Context, id uint) *domain.AppError {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.subscriptions[id]; !ok {
+		errStr := fmt.Sprintf("Webhook subscription not found, ID: %d", id)
+		return domain.NewNotFoundError(errStr)
+	}
+
+	delete(r.subscriptions, id)
+	for deliveryID, d := range r.deliveries {
+		if d.SubscriptionID == id {
+			delete(r.deliveries, deliveryID)
+		}
+	}
+
+	return nil
+}
+
+func (r *memoryRepository) CreateDelivery(ctx context.Context, delivery domain.WebhookDelivery) (domain.WebhookDelivery, *domain.AppError) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextDeliveryID++
+	delivery.ID = r.nextDeliveryID
+	r.deliveries[delivery.ID] = delivery
+
+	return delivery, nil
+}
+
+func (r *memoryRepository) GetDeliveryById(ctx context.Context, id uint) (domain.WebhookDelivery, *domain.AppError) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	d, ok := r.deliveries[id]
+	if !ok {
+		errStr := fmt.Sprintf("Webhook delivery not found, ID: %d", id)
+		return d, domain.NewNotFoundError(errStr)
+	}
+
+	return d, nil
+}
+
+func (r *memoryRepository) UpdateDelivery(ctx context.Context, delivery domain.WebhookDelivery) (domain.WebhookDelivery, *domain.AppError) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.deliveries[delivery.ID] = delivery
+
+	return delivery, nil
+}
+
+func (r *memoryRepository) ListDeliveries(ctx context.Context, subscriptionID uint, limit, offset int) ([]domain.WebhookDelivery, *domain.AppError) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var deliveries []domain.WebhookDelivery
+	for _, d := range r.deliveries {
+		if d.SubscriptionID == subscriptionID {
+			deliveries = append(deliveries, d)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].ID > deliveries[j].ID })
+
+	return page(deliveries, limit, offset), nil
+}
+
+func (r *memoryRepository) ClaimDueDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]domain.WebhookDelivery, *domain.AppError) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deliveries []domain.WebhookDelivery
+	for _, d := range r.deliveries {
+		due := d.Status == domain.DeliveryPending || d.Status == domain.DeliveryRetrying
+		if due && !d.NextAttemptAt.After(now) {
+			deliveries = append(deliveries, d)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].NextAttemptAt.Before(deliveries[j].NextAttemptAt)
+	})
+
+	deliveries = page(deliveries, limit, 0)
+	for i := range deliveries {
+		deliveries[i].NextAttemptAt = leaseUntil
+		r.deliveries[deliveries[i].ID] = deliveries[i]
+	}
+
+	return deliveries, nil
+}
+
+// page возвращает срез элементов с учетом limit и offset
+func page[T any](items []T, limit, offset int) []T {
+	if offset >= len(items) {
+		return []T{}
+	}
+	items = items[offset:]
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
@@ -0,0 +1,38 @@
+package webhook
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSignVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"event":"user.created"}`)
+	header := Sign("secret", now, body)
+
+	tests := []struct {
+		name   string
+		secret string
+		header string
+		body   []byte
+		now    time.Time
+		want   error
+	}{
+		{name: "valid", secret: "secret", header: header, body: body, now: now},
+		{name: "within tolerance", secret: "secret", header: header, body: body, now: now.Add(4 * time.Minute)},
+		{name: "tampered body", secret: "secret", header: header, body: []byte(`{}`), now: now, want: ErrInvalidSignature},
+		{name: "wrong secret", secret: "other", header: header, body: body, now: now, want: ErrInvalidSignature},
+		{name: "expired", secret: "secret", header: header, body: body, now: now.Add(6 * time.Minute), want: ErrSignatureExpired},
+		{name: "malformed header", secret: "secret", header: "v1=abc", body: body, now: now, want: ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.header, tt.body, 5*time.Minute, tt.now)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Verify() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
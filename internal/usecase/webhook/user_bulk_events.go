@@ -0,0 +1,39 @@
+package webhook
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+)
+
+// userBulkEventsUseCase публикует user.created для каждого импортированного
+// пользователя одним вызовом на пакет
+type userBulkEventsUseCase struct {
+	domain.UserBulkUseCase
+	publisher domain.WebhookUseCase
+	log       *slog.Logger
+}
+
+func NewUserBulkEventsUseCase(next domain.UserBulkUseCase, publisher domain.WebhookUseCase, log *slog.Logger) *userBulkEventsUseCase {
+	return &userBulkEventsUseCase{UserBulkUseCase: next, publisher: publisher, log: log}
+}
+
+func (u *userBulkEventsUseCase) ImportUsers(ctx context.Context, users []domain.User) ([]domain.BulkItemResult, *domain.AppError) {
+	results, err := u.UserBulkUseCase.ImportUsers(ctx, users)
+	if err != nil {
+		return results, err
+	}
+
+	var created []any
+	for _, result := range results {
+		if result.Status == domain.BulkItemCreated {
+			created = append(created, result.User)
+		}
+	}
+	if len(created) > 0 {
+		publish(ctx, u.publisher, u.log, domain.EventUserCreated, created...)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,64 @@
+package webhook
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+)
+
+// userEventsUseCase декоратор над domain.UserUseCase, публикующий
+// события жизненного цикла пользователя после успешных изменений
+type userEventsUseCase struct {
+	next      domain.UserUseCase
+	publisher domain.WebhookUseCase
+	log       *slog.Logger
+}
+
+func NewUserEventsUseCase(next domain.UserUseCase, publisher domain.WebhookUseCase, log *slog.Logger) *userEventsUseCase {
+	return &userEventsUseCase{next: next, publisher: publisher, log: log}
+}
+
+func (u *userEventsUseCase) CreateUser(ctx context.Context, user domain.User) (domain.User, *domain.AppError) {
+	created, err := u.next.CreateUser(ctx, user)
+	if err != nil {
+		return created, err
+	}
+
+	publish(ctx, u.publisher, u.log, domain.EventUserCreated, created)
+	return created, nil
+}
+
+func (u *userEventsUseCase) GetUserById(ctx context.Context, id uint) (domain.User, *domain.AppError) {
+	return u.next.GetUserById(ctx, id)
+}
+
+func (u *userEventsUseCase) UpdateUser(ctx context.Context, user domain.User) (domain.User, *domain.AppError) {
+	updated, err := u.next.UpdateUser(ctx, user)
+	if err != nil {
+		return updated, err
+	}
+
+	publish(ctx, u.publisher, u.log, domain.EventUserUpdated, updated)
+	return updated, nil
+}
+
+func (u *userEventsUseCase) DeleteUserById(ctx context.Context, id uint) *domain.AppError {
+	if err := u.next.DeleteUserById(ctx, id); err != nil {
+		return err
+	}
+
+	publish(ctx, u.publisher, u.log, domain.EventUserDeleted, map[string]uint{"id": id})
+	return nil
+}
+
+// publish не прерывает операцию с пользователем: ошибка публикации только логируется.
+// Изменение уже сохранено, поэтому событие публикуется и после отмены запроса
+func publish(ctx context.Context, publisher domain.WebhookUseCase, log *slog.Logger, event domain.WebhookEvent, data ...any) {
+	if err := publisher.Publish(context.WithoutCancel(ctx), event, data...); err != nil {
+		log.ErrorContext(ctx, "failed to publish webhook event",
+			slog.String("event", string(event)),
+			slog.String("error", err.Message),
+		)
+	}
+}
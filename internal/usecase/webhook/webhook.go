@@ -0,0 +1,238 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/Noviiich/golang-new-relic-sentry-prometheus/domain"
+)
+
+type webhookUseCase struct {
+	repo       domain.WebhookRepository
+	dispatcher *Dispatcher
+	log        *slog.Logger
+}
+
+func NewWebhookUseCase(repo domain.WebhookRepository, dispatcher *Dispatcher, log *slog.Logger) *webhookUseCase {
+	return &webhookUseCase{repo: repo, dispatcher: dispatcher, log: log}
+}
+
+// envelope тело запроса, отправляемого подписчику
+type envelope struct {
+	Event      domain.WebhookEvent `json:"event"`
+	OccurredAt time.Time           `json:"occurred_at"`
+	Data       any                 `json:"data"`
+}
+
+func (u *webhookUseCase) CreateSubscription(ctx context.Context, sub domain.WebhookSubscription) (domain.WebhookSubscription, *domain.AppError) {
+	if err := validateSubscription(sub); err != nil {
+		return sub, err
+	}
+
+	if sub.Secret == "" {
+		secret, err := generateSecret()
+		if err != nil {
+			u.log.ErrorContext(ctx, "failed to generate webhook secret", slog.String("error", err.Error()))
+			return sub, domain.NewUnexpectedError("failed to generate webhook secret")
+		}
+		sub.Secret = secret
+	}
+
+	now := time.Now()
+	sub.CreatedAt = now
+	sub.UpdatedAt = now
+
+	created, err := u.repo.CreateSubscription(ctx, sub)
+	if err != nil {
+		u.log.ErrorContext(ctx, err.Message)
+		return domain.WebhookSubscription{}, err
+	}
+
+	u.log.InfoContext(ctx, fmt.Sprintf("Webhook subscription created. ID: %d", created.ID))
+	return created, nil
+}
+
+func (u *webhookUseCase) GetSubscriptionById(ctx context.Context, id uint) (domain.WebhookSubscription, *domain.AppError) {
+	sub, err := u.repo.GetSubscriptionById(ctx, id)
+	if err != nil {
+		u.log.ErrorContext(ctx, err.Message)
+		return sub, err
+	}
+	return sub, nil
+}
+
+func (u *webhookUseCase) ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, *domain.AppError) {
+	subs, err := u.repo.ListSubscriptions(ctx)
+	if err != nil {
+		u.log.ErrorContext(ctx, err.Message)
+		return nil, err
+	}
+	return subs, nil
+}
+
+func (u *webhookUseCase) UpdateSubscription(ctx context.Context, sub domain.WebhookSubscription) (domain.WebhookSubscription, *domain.AppError) {
+	if err := validateSubscription(sub); err != nil {
+		return sub, err
+	}
+
+	existing, err := u.repo.GetSubscriptionById(ctx, sub.ID)
+	if err != nil {
+		u.log.ErrorContext(ctx, err.Message)
+		return sub, err
+	}
+
+	if sub.Secret == "" {
+		sub.Secret = existing.Secret
+	}
+	sub.CreatedAt = existing.CreatedAt
+	sub.UpdatedAt = time.Now()
+
+	updated, err := u.repo.UpdateSubscription(ctx, sub)
+	if err != nil {
+		u.log.ErrorContext(ctx, err.Message)
+		return updated, err
+	}
+	return updated, nil
+}
+
+func (u *webhookUseCase) DeleteSubscriptionById(ctx context.Context, id uint) *domain.AppError {
+	err := u.repo.DeleteSubscriptionById(ctx, id)
+	if err != nil {
+		u.log.ErrorContext(ctx, err.Message)
+		return err
+	}
+	return nil
+}
+
+func (u *webhookUseCase) ListDeliveries(ctx context.Context, subscriptionID uint, limit, offset int) ([]domain.WebhookDelivery, *domain.AppError) {
+	if _, err := u.repo.GetSubscriptionById(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := u.repo.ListDeliveries(ctx, subscriptionID, limit, offset)
+	if err != nil {
+		u.log.ErrorContext(ctx, err.Message)
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// RetryDelivery повторно ставит доставку в очередь,
+// в том числе доставку из dead-letter
+func (u *webhookUseCase) RetryDelivery(ctx context.Context, subscriptionID, deliveryID uint) (domain.WebhookDelivery, *domain.AppError) {
+	delivery, err := u.repo.GetDeliveryById(ctx, deliveryID)
+	if err != nil {
+		return delivery, err
+	}
+
+	if delivery.SubscriptionID != subscriptionID {
+		errStr := fmt.Sprintf("Webhook delivery not found, ID: %d", deliveryID)
+		return domain.WebhookDelivery{}, domain.NewNotFoundError(errStr)
+	}
+
+	if delivery.Status == domain.DeliverySucceeded {
+		return delivery, domain.NewBadRequestError("delivery has already succeeded")
+	}
+
+	now := time.Now()
+	// история попыток сохраняется, лимит MaxAttempts отсчитывается заново
+	delivery.Status = domain.DeliveryPending
+	delivery.Retries++
+	delivery.AttemptsBeforeRetry = delivery.Attempts
+	delivery.NextAttemptAt = now
+	delivery.UpdatedAt = now
+
+	delivery, err = u.repo.UpdateDelivery(ctx, delivery)
+	if err != nil {
+		u.log.ErrorContext(ctx, err.Message)
+		return delivery, err
+	}
+
+	u.dispatcher.Notify()
+	return delivery, nil
+}
+
+// Publish создает доставки для всех активных подписок на событие,
+// по одной на каждый объект data. Подписки читаются один раз на вызов,
+// поэтому пакет событий лучше передавать одним вызовом
+func (u *webhookUseCase) Publish(ctx context.Context, event domain.WebhookEvent, data ...any) *domain.AppError {
+	subs, err := u.repo.ListSubscriptions(ctx)
+	if err != nil {
+		u.log.ErrorContext(ctx, err.Message)
+		return err
+	}
+
+	var matched []domain.WebhookSubscription
+	for _, sub := range subs {
+		if sub.Matches(event) {
+			matched = append(matched, sub)
+		}
+	}
+	if len(matched) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	for _, d := range data {
+		payload, marshalErr := json.Marshal(envelope{Event: event, OccurredAt: now, Data: d})
+		if marshalErr != nil {
+			u.log.ErrorContext(ctx, "failed to marshal webhook payload", slog.String("error", marshalErr.Error()))
+			return domain.NewUnexpectedError("failed to marshal webhook payload")
+		}
+
+		for _, sub := range matched {
+			_, err := u.repo.CreateDelivery(ctx, domain.WebhookDelivery{
+				SubscriptionID: sub.ID,
+				Event:          event,
+				Payload:        payload,
+				Status:         domain.DeliveryPending,
+				NextAttemptAt:  now,
+				CreatedAt:      now,
+				UpdatedAt:      now,
+			})
+			if err != nil {
+				u.log.ErrorContext(ctx, err.Message)
+				return err
+			}
+		}
+	}
+
+	if len(data) > 0 {
+		u.dispatcher.Notify()
+	}
+
+	return nil
+}
+
+func validateSubscription(sub domain.WebhookSubscription) *domain.AppError {
+	target, err := url.ParseRequestURI(sub.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return domain.NewValidationError("The url should be an absolute http(s) URL.")
+	}
+
+	if len(sub.Events) == 0 {
+		return domain.NewValidationError("At least one event should be specified.")
+	}
+
+	for _, event := range sub.Events {
+		if !event.IsValid() {
+			return domain.NewValidationError(fmt.Sprintf("Unknown event: %s.", event))
+		}
+	}
+
+	return nil
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,12 @@
+CREATE TABLE audit_log (
+    id SERIAL PRIMARY KEY,
+    actor TEXT NOT NULL,
+    request_id TEXT NOT NULL DEFAULT '',
+    operation TEXT NOT NULL,
+    resource TEXT NOT NULL,
+    resource_id INT NOT NULL,
+    diff JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX audit_log_resource_idx ON audit_log (resource, resource_id, id DESC);
@@ -0,0 +1,3 @@
+ALTER TABLE webhook_deliveries
+    DROP COLUMN IF EXISTS attempts_before_retry,
+    DROP COLUMN IF EXISTS retries;
@@ -0,0 +1,3 @@
+ALTER TABLE webhook_deliveries
+    ADD COLUMN retries INT NOT NULL DEFAULT 0,
+    ADD COLUMN attempts_before_retry INT NOT NULL DEFAULT 0;
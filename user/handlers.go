@@ -29,7 +29,7 @@ func (h *Handler) CreateUser(c *gin.Context) {
 			return
 		}
 
-		createUser, err := h.userUseCase.CreateUser(user)
+		createUser, err := h.userUseCase.CreateUser(c.Request.Context(), user)
 		if err != nil {
 			hub.CaptureException(errors.New(err.Message))
 			c.JSON(err.Code, err.AsMessageError())
@@ -44,7 +44,7 @@ func (h *Handler) GetUserById(c *gin.Context) {
 		idParam := c.Param("id")
 		id, _ := strconv.ParseInt(idParam, 10, 64)
 
-		user, err := h.userUseCase.GetUserById(uint(id))
+		user, err := h.userUseCase.GetUserById(c.Request.Context(), uint(id))
 		if err != nil {
 			hub.CaptureException(errors.New(err.Message))
 			c.JSON(err.Code, err.AsMessageError())
@@ -61,7 +61,7 @@ func (h *Handler) UpdateUser(c *gin.Context) {
 			c.JSON(400, domain.NewBadRequestError("bad request"))
 		}
 
-		updatedUser, err := h.userUseCase.UpdateUser(user)
+		updatedUser, err := h.userUseCase.UpdateUser(c.Request.Context(), user)
 		if err != nil {
 			hub.CaptureException(errors.New(err.Message))
 			c.JSON(err.Code, err.AsMessageError())
@@ -76,7 +76,7 @@ func (h *Handler) DeleteUserById(c *gin.Context) {
 		idParam := c.Param("id")
 		id, _ := strconv.ParseInt(idParam, 10, 64)
 
-		err := h.userUseCase.DeleteUserById(uint(id))
+		err := h.userUseCase.DeleteUserById(c.Request.Context(), uint(id))
 		if err != nil {
 			hub.CaptureException(errors.New(err.Message))
 			c.JSON(err.Code, err.AsMessageError())
@@ -1,6 +1,7 @@
 package user
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -16,18 +17,18 @@ func NewUserRepository(db *gorm.DB) *userRepository {
 	return &userRepository{db: db}
 }
 
-func (r *userRepository) CreateUser(user domain.User) (domain.User, *domain.AppError) {
-	err := r.db.Create(&user).Error
+func (r *userRepository) CreateUser(ctx context.Context, user domain.User) (domain.User, *domain.AppError) {
+	err := r.db.WithContext(ctx).Create(&user).Error
 	if err != nil {
 		return user, domain.NewUnexpectedError(err.Error())
 	}
 	return user, nil
 }
 
-func (r *userRepository) GetUserById(id uint) (domain.User, *domain.AppError) {
+func (r *userRepository) GetUserById(ctx context.Context, id uint) (domain.User, *domain.AppError) {
 	var user domain.User
 	
-	err := r.db.Where("id = ?", id).First(&user).Error
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&user).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		errStr := fmt.Sprintf("User not found, ID: %d", id)
 		return user, domain.NewNotFoundError(errStr)
@@ -40,16 +41,16 @@ func (r *userRepository) GetUserById(id uint) (domain.User, *domain.AppError) {
 	return user, nil
 }
 
-func (r *userRepository) UpdateUser(user domain.User) (domain.User, *domain.AppError) {
-	err := r.db.Save(&user).Error
+func (r *userRepository) UpdateUser(ctx context.Context, user domain.User) (domain.User, *domain.AppError) {
+	err := r.db.WithContext(ctx).Save(&user).Error
 	if err != nil {
 		return user, domain.NewUnexpectedError(err.Error())
 	}
 	return user, nil
 }
 
-func (r *userRepository) DeleteUserById(id uint) *domain.AppError {
-	err := r.db.Delete(&domain.User{}, id).Error
+func (r *userRepository) DeleteUserById(ctx context.Context, id uint) *domain.AppError {
+	err := r.db.WithContext(ctx).Delete(&domain.User{}, id).Error
 	if err != nil {
 		return domain.NewUnexpectedError(err.Error())
 	}
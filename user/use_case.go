@@ -1,6 +1,7 @@
 package user
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -17,15 +18,14 @@ func NewUserUseCase(repo domain.UserRepository, logger *zap.Logger) *userUseCase
 	return &userUseCase{repo: repo, logger: logger}
 }
 
-func (u *userUseCase) CreateUser(user domain.User) (domain.User, *domain.AppError) {
+func (u *userUseCase) CreateUser(ctx context.Context, user domain.User) (domain.User, *domain.AppError) {
 	user.CreatedDate = time.Now()
-	if user.Name == "" {
-		err := domain.NewValidationError("The name should not be empty.")
+	if err := user.Validate(); err != nil {
 		u.logger.Error(err.Message)
 		return user, err
 	}
 
-	createdUser, err := u.repo.CreateUser(user)
+	createdUser, err := u.repo.CreateUser(ctx, user)
 	if err != nil {
 		u.logger.Error(err.Message)
 		return domain.User{}, err
@@ -35,8 +35,8 @@ func (u *userUseCase) CreateUser(user domain.User) (domain.User, *domain.AppErro
 	return createdUser, nil
 }
 
-func (u *userUseCase) GetUserById(id uint) (domain.User, *domain.AppError) {
-	user, err := u.repo.GetUserById(id)
+func (u *userUseCase) GetUserById(ctx context.Context, id uint) (domain.User, *domain.AppError) {
+	user, err := u.repo.GetUserById(ctx, id)
 	if err != nil {
 		u.logger.Error(err.Message)
 		return user, err
@@ -45,8 +45,13 @@ func (u *userUseCase) GetUserById(id uint) (domain.User, *domain.AppError) {
 	return user, nil
 }
 
-func (u *userUseCase) UpdateUser(user domain.User) (domain.User, *domain.AppError) {
-	updatedUser, err := u.repo.UpdateUser(user)
+func (u *userUseCase) UpdateUser(ctx context.Context, user domain.User) (domain.User, *domain.AppError) {
+	if err := user.Validate(); err != nil {
+		u.logger.Error(err.Message)
+		return user, err
+	}
+
+	updatedUser, err := u.repo.UpdateUser(ctx, user)
 	if err != nil {
 		u.logger.Error(err.Message)
 		return updatedUser, err
@@ -54,8 +59,8 @@ func (u *userUseCase) UpdateUser(user domain.User) (domain.User, *domain.AppErro
 	return updatedUser, nil
 }
 
-func (u *userUseCase) DeleteUserById(id uint) *domain.AppError {
-	err := u.repo.DeleteUserById(id)
+func (u *userUseCase) DeleteUserById(ctx context.Context, id uint) *domain.AppError {
+	err := u.repo.DeleteUserById(ctx, id)
 	if err != nil {
 		u.logger.Error(err.Message)
 		return err